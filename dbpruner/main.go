@@ -3,7 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
 	"time"
@@ -67,13 +67,13 @@ func findOldJobs(ctx context.Context, collection *mongo.Collection, cutoffDate t
 
 	for _, job := range allJobs {
 		if job.StartedAt == "" {
-			log.Printf("Job %s has empty started_at field, skipping", job.ID)
+			slog.WarnContext(ctx, "Job has empty started_at field, skipping", "build_id", job.ID, "test_name", job.TestName)
 			continue
 		}
 
 		startedAt, err := parseStartedAt(job.StartedAt)
 		if err != nil {
-			log.Printf("Job %s has invalid started_at format '%s': %v, skipping", job.ID, job.StartedAt, err)
+			slog.WarnContext(ctx, "Job has invalid started_at field, skipping", "build_id", job.ID, "test_name", job.TestName, "started_at", job.StartedAt, "error", err)
 			continue
 		}
 
@@ -91,7 +91,7 @@ func deleteJobs(ctx context.Context, collection *mongo.Collection, jobIDs []stri
 	}
 
 	if dryRun {
-		log.Printf("[DRY RUN] Would delete %d jobs with IDs: %v", len(jobIDs), jobIDs)
+		slog.InfoContext(ctx, "[DRY RUN] Would delete jobs", "count", len(jobIDs), "build_ids", jobIDs)
 		return int64(len(jobIDs)), nil
 	}
 
@@ -114,7 +114,7 @@ func runCleanup(config CleanupConfig) error {
 	}
 	defer func() {
 		if err := client.Disconnect(ctx); err != nil {
-			log.Printf("Error disconnecting MongoDB: %v", err)
+			slog.ErrorContext(ctx, "Error disconnecting MongoDB", "error", err)
 		}
 	}()
 
@@ -123,19 +123,20 @@ func runCleanup(config CleanupConfig) error {
 	// Calculate cutoff date
 	cutoffDate := time.Now().AddDate(0, 0, -config.RetentionDays)
 
-	log.Printf("Starting cleanup process...")
-	log.Printf("Retention period: %d days", config.RetentionDays)
-	log.Printf("Cutoff date: %s", cutoffDate.Format(time.RFC3339))
-	log.Printf("Test name filter: %s", config.TestName)
-	log.Printf("Dry run mode: %v", config.DryRun)
-	log.Printf("Batch size: %d", config.BatchSize)
+	slog.InfoContext(ctx, "Starting cleanup process",
+		"retention_days", config.RetentionDays,
+		"cutoff", cutoffDate.Format(time.RFC3339),
+		"test_name", config.TestName,
+		"dry_run", config.DryRun,
+		"batch_size", config.BatchSize)
+	start := time.Now()
 
 	totalDeleted := int64(0)
 	batchCount := 0
 
 	for {
 		batchCount++
-		log.Printf("Processing batch %d...", batchCount)
+		slog.DebugContext(ctx, "Processing batch", "batch", batchCount)
 
 		// Find old jobs in batches
 		oldJobs, err := findOldJobs(ctx, collection, cutoffDate, config.TestName, config.BatchSize)
@@ -144,17 +145,17 @@ func runCleanup(config CleanupConfig) error {
 		}
 
 		if len(oldJobs) == 0 {
-			log.Printf("No more old jobs found. Cleanup complete.")
+			slog.InfoContext(ctx, "No more old jobs found")
 			break
 		}
 
-		log.Printf("Found %d jobs older than %s in batch %d", len(oldJobs), cutoffDate.Format("2006-01-02"), batchCount)
+		slog.InfoContext(ctx, "Found old jobs", "batch", batchCount, "count", len(oldJobs), "cutoff", cutoffDate.Format("2006-01-02"))
 
 		// Extract job IDs for deletion
 		jobIDs := make([]string, len(oldJobs))
 		for i, job := range oldJobs {
 			jobIDs[i] = job.ID
-			log.Printf("  - Job %s (%s) started at %s", job.ID, job.TestName, job.StartedAt)
+			slog.DebugContext(ctx, "Old job", "build_id", job.ID, "test_name", job.TestName, "pr", job.PR, "started_at", job.StartedAt)
 		}
 
 		// Delete the jobs
@@ -164,11 +165,14 @@ func runCleanup(config CleanupConfig) error {
 		}
 
 		totalDeleted += deletedCount
+		if !config.DryRun {
+			jobsDeleted.Add(float64(deletedCount))
+		}
 
 		if config.DryRun {
-			log.Printf("[DRY RUN] Batch %d: Would delete %d jobs", batchCount, deletedCount)
+			slog.InfoContext(ctx, "[DRY RUN] Would delete batch", "batch", batchCount, "count", deletedCount)
 		} else {
-			log.Printf("Batch %d: Successfully deleted %d jobs", batchCount, deletedCount)
+			slog.InfoContext(ctx, "Deleted batch", "batch", batchCount, "count", deletedCount)
 		}
 
 		// If we got fewer jobs than the batch size, we're done
@@ -178,9 +182,9 @@ func runCleanup(config CleanupConfig) error {
 	}
 
 	if config.DryRun {
-		log.Printf("Cleanup complete! [DRY RUN] Would have deleted %d total jobs", totalDeleted)
+		slog.InfoContext(ctx, "[DRY RUN] Cleanup complete", "would_delete", totalDeleted, "duration", time.Since(start))
 	} else {
-		log.Printf("Cleanup complete! Successfully deleted %d total jobs", totalDeleted)
+		slog.InfoContext(ctx, "Cleanup complete", "deleted", totalDeleted, "duration", time.Since(start))
 	}
 
 	return nil
@@ -188,14 +192,25 @@ func runCleanup(config CleanupConfig) error {
 
 func createRootCommand() *cobra.Command {
 	var config CleanupConfig
+	var logFormat, logLevel string
 
 	cmd := &cobra.Command{
 		Use:   "dbpruner",
 		Short: "Clean up old test results from the CI TestGrid database",
 		Long: `A tool that removes old test results from the MongoDB database used by the CI TestGrid scraper.
 This helps manage database size and performance by removing outdated test results.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return setupLogging(os.Stderr, logFormat, logLevel)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runCleanup(config)
+			err := runCleanup(config)
+			if err == nil {
+				lastSuccessTimestamp.SetToCurrentTime()
+			}
+			if pushErr := pushMetrics(); pushErr != nil {
+				slog.Error("Error pushing metrics", "error", pushErr)
+			}
+			return err
 		},
 	}
 
@@ -203,6 +218,8 @@ This helps manage database size and performance by removing outdated test result
 	cmd.Flags().BoolVar(&config.DryRun, "dry-run", false, "Run in dry-run mode without actually deleting anything")
 	cmd.Flags().IntVar(&config.BatchSize, "batch-size", 1000, "Number of jobs to process in each batch (default: 1000)")
 	cmd.Flags().StringVar(&config.TestName, "test-name", "", "Only clean up jobs for a specific test name (e.g., 'e2e-aws', 'e2e-aks')")
+	cmd.Flags().StringVar(&logFormat, "log-format", "text", "Log format, text or json")
+	cmd.Flags().StringVar(&logLevel, "log-level", "info", "Log level: debug, info, warn or error")
 
 	return cmd
 }
@@ -210,12 +227,12 @@ This helps manage database size and performance by removing outdated test result
 func init() {
 	// Check for environment variable overrides
 	if dryRun := os.Getenv("DRY_RUN"); dryRun != "" {
-		log.Println("DRY_RUN environment variable detected - enabling dry run mode")
+		slog.Info("DRY_RUN environment variable detected - enabling dry run mode")
 	}
 
 	if retentionDays := os.Getenv("RETENTION_DAYS"); retentionDays != "" {
 		if _, err := strconv.Atoi(retentionDays); err == nil {
-			log.Printf("RETENTION_DAYS environment variable detected - using %s days", retentionDays)
+			slog.Info("RETENTION_DAYS environment variable detected", "retention_days", retentionDays)
 		}
 	}
 }
@@ -236,6 +253,6 @@ func main() {
 	}
 
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+		os.Exit(1)
 	}
 }
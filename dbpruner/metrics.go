@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// metricsRegistry holds the pruner's metrics, which are pushed to a
+// Pushgateway when a run completes since the pruner runs as a CronJob.
+var metricsRegistry = prometheus.NewRegistry()
+
+var (
+	jobsDeleted = promauto.With(metricsRegistry).NewCounter(prometheus.CounterOpts{
+		Name: "dbpruner_jobs_deleted_total",
+		Help: "Job documents deleted from MongoDB.",
+	})
+	lastSuccessTimestamp = promauto.With(metricsRegistry).NewGauge(prometheus.GaugeOpts{
+		Name: "dbpruner_last_success_timestamp_seconds",
+		Help: "Unix time the pruner last completed a run without errors.",
+	})
+)
+
+// pushMetrics pushes the metrics to the Pushgateway at PUSHGATEWAY_URL, if set.
+func pushMetrics() error {
+	url := os.Getenv("PUSHGATEWAY_URL")
+	if url == "" {
+		return nil
+	}
+	return push.New(url, "dbpruner").Gatherer(metricsRegistry).Push()
+}
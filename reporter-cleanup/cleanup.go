@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/go-github/v45/github"
+)
+
+// Cleaner deletes or minimizes the reporter's comments on PRs.
+type Cleaner struct {
+	github      *github.Client
+	graphql     *graphQLClient
+	currentUser string
+	config      Config
+}
+
+// Action records what was done with one comment.
+type Action struct {
+	PR        int
+	CommentID int64
+	Action    string
+	Reason    string
+	Err       error
+}
+
+// Report summarizes a cleanup run.
+type Report struct {
+	PRs      int
+	Comments int
+	Actions  []Action
+}
+
+// Run cleans up matching comments on the selected PRs. Errors for individual
+// PRs and comments are recorded in the report and do not stop the run.
+func (c *Cleaner) Run(ctx context.Context) (*Report, error) {
+	prs, err := c.prNumbers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	now := time.Now()
+	for _, prNumber := range prs {
+		prCtx := withLogFields(ctx, "pr", prNumber)
+		loggerFrom(prCtx).Info("Processing PR")
+		report.PRs++
+
+		comments, err := c.listComments(prCtx, prNumber)
+		if err != nil {
+			loggerFrom(prCtx).Error("Error listing comments", "error", err)
+			report.Actions = append(report.Actions, Action{PR: prNumber, Action: "error", Err: err})
+			continue
+		}
+
+		// Find and clean up comments from the reporter
+		for _, comment := range comments {
+			if comment.GetUser().GetLogin() != c.currentUser || !strings.Contains(comment.GetBody(), commentMarker) {
+				continue
+			}
+			report.Comments++
+
+			reason, ok := c.matches(comment, now)
+			if !ok {
+				continue
+			}
+			report.Actions = append(report.Actions, c.cleanup(prCtx, prNumber, comment, reason))
+		}
+	}
+
+	return report, nil
+}
+
+// prNumbers returns the PRs to clean up: the configured ones, or every PR in
+// the configured state.
+func (c *Cleaner) prNumbers(ctx context.Context) ([]int, error) {
+	if len(c.config.PRs) > 0 {
+		return c.config.PRs, nil
+	}
+
+	var numbers []int
+	opts := &github.PullRequestListOptions{
+		State:       c.config.PRState,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		prs, resp, err := c.github.PullRequests.List(ctx, repoOwner, repoName, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing %s PRs: %v", c.config.PRState, err)
+		}
+		for _, pr := range prs {
+			numbers = append(numbers, pr.GetNumber())
+		}
+		if resp.NextPage == 0 {
+			return numbers, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// listComments returns every comment on a PR.
+func (c *Cleaner) listComments(ctx context.Context, prNumber int) ([]*github.IssueComment, error) {
+	var comments []*github.IssueComment
+	opts := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		page, resp, err := c.github.Issues.ListComments(ctx, repoOwner, repoName, prNumber, opts)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, page...)
+		if resp.NextPage == 0 {
+			return comments, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// matches reports whether a reporter comment is selected by the configured
+// filters, along with a description of why.
+func (c *Cleaner) matches(comment *github.IssueComment, now time.Time) (string, bool) {
+	var reasons []string
+
+	if c.config.OlderThan > 0 {
+		updated := comment.GetUpdatedAt()
+		if updated.IsZero() {
+			updated = comment.GetCreatedAt()
+		}
+		age := now.Sub(updated)
+		if age < c.config.OlderThan {
+			return "", false
+		}
+		reasons = append(reasons, fmt.Sprintf("last updated %d days ago", int(age.Hours()/24)))
+	}
+
+	if c.config.StaleTestTypes {
+		stale := staleTestTypes(comment.GetBody(), c.config.TestTypes)
+		if len(stale) == 0 {
+			return "", false
+		}
+		reasons = append(reasons, "reports on unconfigured test types: "+strings.Join(stale, ", "))
+	}
+
+	if len(reasons) == 0 {
+		return "reporter comment", true
+	}
+	return strings.Join(reasons, "; "), true
+}
+
+// staleTestTypes returns the test types a comment reports on that are not in
+// configured. The test types are read from the hidden job IDs marker, which
+// the reporter writes as <test type>:<job ID> pairs whatever the comment
+// template, rather than from the rendered comment. Comments written before
+// the marker held test types report none.
+func staleTestTypes(body string, configured []string) []string {
+	_, rest, ok := strings.Cut(body, jobIDsMarker)
+	if !ok {
+		return nil
+	}
+	jobIDs, _, ok := strings.Cut(rest, " -->")
+	if !ok {
+		return nil
+	}
+
+	var stale []string
+	for _, pair := range strings.Split(jobIDs, ",") {
+		testType, _, ok := strings.Cut(pair, ":")
+		if !ok || testType == "" || slices.Contains(configured, testType) {
+			continue
+		}
+		stale = append(stale, testType)
+	}
+	return stale
+}
+
+// cleanup deletes or minimizes a single comment.
+func (c *Cleaner) cleanup(ctx context.Context, prNumber int, comment *github.IssueComment, reason string) Action {
+	action := Action{PR: prNumber, CommentID: comment.GetID(), Reason: reason}
+
+	verb := "delete"
+	if c.config.Minimize {
+		verb = "minimize"
+
+		// Don't minimize a comment twice
+		minimized, err := c.graphql.isMinimized(ctx, comment.GetNodeID())
+		if err != nil {
+			loggerFrom(ctx).Error("Error checking whether comment is minimized", "comment_id", action.CommentID, "error", err)
+			action.Action = "error"
+			action.Err = err
+			return action
+		}
+		if minimized {
+			loggerFrom(ctx).Info("Comment is already minimized", "comment_id", action.CommentID)
+			action.Action = "already minimized"
+			return action
+		}
+	}
+
+	if c.config.DryRun {
+		loggerFrom(ctx).Info("[DRY RUN] Would clean up comment", "action", verb, "comment_id", action.CommentID, "reason", reason)
+		action.Action = "would " + verb
+		return action
+	}
+
+	var err error
+	if c.config.Minimize {
+		err = c.graphql.minimizeComment(ctx, comment.GetNodeID())
+	} else {
+		_, err = c.github.Issues.DeleteComment(ctx, repoOwner, repoName, action.CommentID)
+	}
+	if err != nil {
+		loggerFrom(ctx).Error("Error cleaning up comment", "action", verb, "comment_id", action.CommentID, "error", err)
+		action.Action = "error"
+		action.Err = err
+		return action
+	}
+
+	loggerFrom(ctx).Info("Cleaned up comment", "action", verb, "comment_id", action.CommentID, "reason", reason)
+	action.Action = verb + "d"
+	return action
+}
+
+// Print writes a summary of the run followed by every action taken.
+func (r *Report) Print(w io.Writer) {
+	counts := make(map[string]int)
+	for _, a := range r.Actions {
+		counts[a.Action]++
+	}
+
+	fmt.Fprintf(w, "\nCleanup report\n")
+	fmt.Fprintf(w, "PRs examined:              %d\n", r.PRs)
+	fmt.Fprintf(w, "Reporter comments found:   %d\n", r.Comments)
+	for _, action := range []string{"deleted", "minimized", "already minimized", "would delete", "would minimize", "error"} {
+		if counts[action] > 0 {
+			fmt.Fprintf(w, "Comments %-17s%d\n", action+":", counts[action])
+		}
+	}
+
+	if len(r.Actions) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PR\tCOMMENT\tACTION\tREASON")
+	for _, a := range r.Actions {
+		reason := a.Reason
+		if a.Err != nil {
+			reason = a.Err.Error()
+		}
+		fmt.Fprintf(tw, "#%d\t%d\t%s\t%s\n", a.PR, a.CommentID, a.Action, reason)
+	}
+	tw.Flush()
+}
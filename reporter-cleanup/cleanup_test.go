@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v45/github"
+)
+
+// reporterBody returns a reporter comment reporting jobIDs, with text in
+// place of the rendered template.
+func reporterBody(jobIDs, text string) string {
+	return commentMarker + "\n" + jobIDsMarker + jobIDs + " -->\n\n" + text
+}
+
+func TestStaleTestTypes(t *testing.T) {
+	configured := []string{"e2e-aws", "e2e-aks"}
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "configured test types",
+			body: reporterBody("e2e-aks:1002,e2e-aws:1001", "## Test Results"),
+		},
+		{
+			name: "one stale test type",
+			body: reporterBody("e2e-aws:1001,e2e-gcp:1003", "## Test Results"),
+			want: []string{"e2e-gcp"},
+		},
+		{
+			name: "only stale test types",
+			body: reporterBody("e2e-azure:1004,e2e-gcp:1003", ""),
+			want: []string{"e2e-azure", "e2e-gcp"},
+		},
+		{
+			// The rendered comment doesn't matter, whatever its template
+			name: "custom template",
+			body: reporterBody("e2e-aws:1001", "### e2e-gcp\n#### Results for e2e-kubevirt"),
+		},
+		{
+			name: "custom template with a stale test type",
+			body: reporterBody("e2e-kubevirt:1005", "<b>e2e-kubevirt</b> passed"),
+			want: []string{"e2e-kubevirt"},
+		},
+		{
+			// Comments written before the marker held test types
+			name: "job IDs only",
+			body: reporterBody("1001,1002", "### e2e-gcp"),
+		},
+		{
+			name: "no marker",
+			body: commentMarker + "\n### e2e-gcp",
+		},
+		{
+			name: "unterminated marker",
+			body: commentMarker + "\n" + jobIDsMarker + "e2e-gcp:1003",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := staleTestTypes(tt.body, configured); !slices.Equal(got, tt.want) {
+				t.Errorf("staleTestTypes = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	comment := func(jobIDs string, created, updated time.Time) *github.IssueComment {
+		c := &github.IssueComment{Body: github.String(reporterBody(jobIDs, ""))}
+		if !created.IsZero() {
+			c.CreatedAt = &created
+		}
+		if !updated.IsZero() {
+			c.UpdatedAt = &updated
+		}
+		return c
+	}
+	daysAgo := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+
+	tests := []struct {
+		name       string
+		config     Config
+		comment    *github.IssueComment
+		wantReason string
+		wantMatch  bool
+	}{
+		{
+			name:       "no filters",
+			comment:    comment("e2e-aws:1001", daysAgo(1), daysAgo(1)),
+			wantReason: "reporter comment",
+			wantMatch:  true,
+		},
+		{
+			name:       "old enough",
+			config:     Config{OlderThan: 14 * 24 * time.Hour},
+			comment:    comment("e2e-aws:1001", daysAgo(30), daysAgo(20)),
+			wantReason: "last updated 20 days ago",
+			wantMatch:  true,
+		},
+		{
+			name:    "updated recently",
+			config:  Config{OlderThan: 14 * 24 * time.Hour},
+			comment: comment("e2e-aws:1001", daysAgo(30), daysAgo(2)),
+		},
+		{
+			name:       "never updated",
+			config:     Config{OlderThan: 14 * 24 * time.Hour},
+			comment:    comment("e2e-aws:1001", daysAgo(15), time.Time{}),
+			wantReason: "last updated 15 days ago",
+			wantMatch:  true,
+		},
+		{
+			name:       "stale test type",
+			config:     Config{StaleTestTypes: true, TestTypes: []string{"e2e-aws"}},
+			comment:    comment("e2e-aws:1001,e2e-gcp:1003", daysAgo(1), daysAgo(1)),
+			wantReason: "reports on unconfigured test types: e2e-gcp",
+			wantMatch:  true,
+		},
+		{
+			name:    "configured test types",
+			config:  Config{StaleTestTypes: true, TestTypes: []string{"e2e-aws", "e2e-gcp"}},
+			comment: comment("e2e-aws:1001,e2e-gcp:1003", daysAgo(1), daysAgo(1)),
+		},
+		{
+			name:       "old with a stale test type",
+			config:     Config{OlderThan: 14 * 24 * time.Hour, StaleTestTypes: true, TestTypes: []string{"e2e-aws"}},
+			comment:    comment("e2e-gcp:1003", daysAgo(30), daysAgo(30)),
+			wantReason: "last updated 30 days ago; reports on unconfigured test types: e2e-gcp",
+			wantMatch:  true,
+		},
+		{
+			// Filters combine
+			name:    "old without a stale test type",
+			config:  Config{OlderThan: 14 * 24 * time.Hour, StaleTestTypes: true, TestTypes: []string{"e2e-aws"}},
+			comment: comment("e2e-aws:1001", daysAgo(30), daysAgo(30)),
+		},
+		{
+			name:    "recent with a stale test type",
+			config:  Config{OlderThan: 14 * 24 * time.Hour, StaleTestTypes: true, TestTypes: []string{"e2e-aws"}},
+			comment: comment("e2e-gcp:1003", daysAgo(30), daysAgo(1)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Cleaner{config: tt.config}
+			reason, ok := c.matches(tt.comment, now)
+			if ok != tt.wantMatch || reason != tt.wantReason {
+				t.Errorf("matches = %q, %v, want %q, %v", reason, ok, tt.wantReason, tt.wantMatch)
+			}
+		})
+	}
+}
+
+// fakeGraphQL answers isMinimized queries for the comments in minimized and
+// records the comments it is asked to minimize.
+type fakeGraphQL struct {
+	minimized map[string]bool
+	mutations []string
+}
+
+func (f *fakeGraphQL) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id, _ := req.Variables["id"].(string)
+	if strings.HasPrefix(req.Query, "mutation") {
+		f.mutations = append(f.mutations, id)
+		f.minimized[id] = true
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"minimizeComment": map[string]any{"minimizedComment": map[string]any{"isMinimized": true}}}})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"node": map[string]any{"isMinimized": f.minimized[id]}}})
+}
+
+func TestCleanupMinimize(t *testing.T) {
+	tests := []struct {
+		name          string
+		dryRun        bool
+		minimized     bool
+		wantAction    string
+		wantMutations []string
+	}{
+		{
+			name:          "visible comment",
+			wantAction:    "minimized",
+			wantMutations: []string{"IC_1"},
+		},
+		{
+			name:       "minimized comment",
+			minimized:  true,
+			wantAction: "already minimized",
+		},
+		{
+			name:       "visible comment in dry run",
+			dryRun:     true,
+			wantAction: "would minimize",
+		},
+		{
+			name:       "minimized comment in dry run",
+			dryRun:     true,
+			minimized:  true,
+			wantAction: "already minimized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeGraphQL{minimized: map[string]bool{"IC_1": tt.minimized}}
+			server := httptest.NewServer(fake)
+			defer server.Close()
+			c := &Cleaner{
+				graphql: &graphQLClient{httpClient: server.Client(), url: server.URL},
+				config:  Config{Minimize: true, DryRun: tt.dryRun},
+			}
+
+			comment := &github.IssueComment{ID: github.Int64(1), NodeID: github.String("IC_1")}
+			action := c.cleanup(context.Background(), 1234, comment, "reporter comment")
+			if action.Action != tt.wantAction || action.Err != nil {
+				t.Errorf("action = %q, %v, want %q", action.Action, action.Err, tt.wantAction)
+			}
+			if !slices.Equal(fake.mutations, tt.wantMutations) {
+				t.Errorf("minimized %q, want %q", fake.mutations, tt.wantMutations)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// graphQLClient sends requests to the GitHub GraphQL API, for operations the
+// REST API doesn't support.
+type graphQLClient struct {
+	httpClient *http.Client
+	url        string
+}
+
+func newGraphQLClient(httpClient *http.Client) *graphQLClient {
+	return &graphQLClient{
+		httpClient: httpClient,
+		url:        "https://api.github.com/graphql",
+	}
+}
+
+const minimizeCommentMutation = `mutation($id: ID!) {
+  minimizeComment(input: {subjectId: $id, classifier: OUTDATED}) {
+    minimizedComment { isMinimized }
+  }
+}`
+
+// minimizeComment hides a comment as outdated.
+func (c *graphQLClient) minimizeComment(ctx context.Context, nodeID string) error {
+	if nodeID == "" {
+		return errors.New("comment has no node ID")
+	}
+	return c.do(ctx, minimizeCommentMutation, map[string]any{"id": nodeID})
+}
+
+const isMinimizedQuery = `query($id: ID!) {
+  node(id: $id) {
+    ... on IssueComment { isMinimized }
+  }
+}`
+
+// isMinimized reports whether a comment is minimized. The REST API doesn't
+// return it.
+func (c *graphQLClient) isMinimized(ctx context.Context, nodeID string) (bool, error) {
+	if nodeID == "" {
+		return false, errors.New("comment has no node ID")
+	}
+	var data struct {
+		Node struct {
+			IsMinimized bool `json:"isMinimized"`
+		} `json:"node"`
+	}
+	if err := c.query(ctx, isMinimizedQuery, map[string]any{"id": nodeID}, &data); err != nil {
+		return false, err
+	}
+	return data.Node.IsMinimized, nil
+}
+
+// do runs a GraphQL query or mutation, discarding the returned data.
+func (c *graphQLClient) do(ctx context.Context, query string, variables map[string]any) error {
+	return c.query(ctx, query, variables, nil)
+}
+
+// query runs a GraphQL query or mutation and decodes the returned data into
+// data, unless it is nil.
+func (c *graphQLClient) query(ctx context.Context, query string, variables map[string]any, data any) error {
+	body, err := json.Marshal(map[string]any{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GraphQL request returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decoding GraphQL response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("GraphQL error: %s", result.Errors[0].Message)
+	}
+	if data != nil {
+		if err := json.Unmarshal(result.Data, data); err != nil {
+			return fmt.Errorf("decoding GraphQL data: %w", err)
+		}
+	}
+	return nil
+}
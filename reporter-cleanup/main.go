@@ -2,9 +2,14 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v45/github"
 	"golang.org/x/oauth2"
@@ -12,19 +17,68 @@ import (
 
 const (
 	commentMarker = "<!-- Test Results Reporter -->"
+	jobIDsMarker  = "<!-- Job IDs: "
+	repoOwner     = "openshift"
+	repoName      = "hypershift"
 )
 
+// Config selects which reporter comments are cleaned up and how.
+type Config struct {
+	// PRs limits cleanup to these PR numbers, open or closed. If empty, PRs
+	// are listed by PRState.
+	PRs     []int
+	PRState string
+	// OlderThan, if non-zero, limits cleanup to comments last updated longer
+	// ago than this.
+	OlderThan time.Duration
+	// StaleTestTypes limits cleanup to comments reporting on at least one
+	// test type that isn't in TestTypes.
+	StaleTestTypes bool
+	TestTypes      []string
+	// Minimize hides comments as outdated instead of deleting them.
+	Minimize bool
+	DryRun   bool
+}
+
 func main() {
+	var config Config
+	prs := flag.String("prs", "", "Comma-separated PR numbers to clean up, open or closed (default: all PRs in --pr-state)")
+	flag.StringVar(&config.PRState, "pr-state", "open", "State of the PRs to clean up when --prs is not set: open, closed or all")
+	olderThanDays := flag.Int("older-than-days", 0, "Only clean up comments last updated more than this many days ago (0 disables)")
+	flag.BoolVar(&config.StaleTestTypes, "stale-test-types", false, "Only clean up comments reporting on test types not listed in --test-types")
+	testTypes := flag.String("test-types", "e2e-aws,e2e-aks", "Comma-separated test types the reporter is configured for")
+	flag.BoolVar(&config.Minimize, "minimize", false, "Minimize comments as outdated instead of deleting them")
+	flag.BoolVar(&config.DryRun, "dry-run", false, "Only report what would be cleaned up")
+	logFormat := flag.String("log-format", "text", "Log format, text or json")
+	logLevel := flag.String("log-level", "info", "Log level: debug, info, warn or error")
+	flag.Parse()
+
+	if err := setupLogging(os.Stderr, *logFormat, *logLevel); err != nil {
+		fatal(err)
+	}
+
+	for _, s := range splitList(*prs) {
+		pr, err := strconv.Atoi(s)
+		if err != nil {
+			fatal(fmt.Errorf("invalid PR number %q", s))
+		}
+		config.PRs = append(config.PRs, pr)
+	}
+	config.OlderThan = time.Duration(*olderThanDays) * 24 * time.Hour
+	config.TestTypes = splitList(*testTypes)
+
 	// Get GitHub token from environment
 	githubToken := os.Getenv("GITHUB_TOKEN")
 	if githubToken == "" {
-		log.Fatal("GITHUB_TOKEN environment variable is required")
+		fatal(errors.New("GITHUB_TOKEN environment variable is required"))
 	}
 
 	// Check for dry run mode
-	dryRun := os.Getenv("DRY_RUN") != ""
-	if dryRun {
-		log.Println("Running in dry run mode - no comments will be deleted")
+	if os.Getenv("DRY_RUN") != "" {
+		config.DryRun = true
+	}
+	if config.DryRun {
+		slog.Info("Running in dry run mode - no comments will be deleted or minimized")
 	}
 
 	// Create GitHub client
@@ -38,44 +92,36 @@ func main() {
 	// Get current user
 	user, _, err := githubClient.Users.Get(ctx, "")
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
-	currentUser := *user.Login
 
-	// Get all open PRs
-	prs, _, err := githubClient.PullRequests.List(ctx, "openshift", "hypershift", &github.PullRequestListOptions{
-		State: "open",
-	})
-	if err != nil {
-		log.Fatal(err)
+	cleaner := &Cleaner{
+		github:      githubClient,
+		graphql:     newGraphQLClient(tc),
+		currentUser: user.GetLogin(),
+		config:      config,
 	}
 
-	// Process each PR
-	for _, pr := range prs {
-		prNumber := *pr.Number
-		log.Printf("Processing PR #%d", prNumber)
+	report, err := cleaner.Run(ctx)
+	if err != nil {
+		fatal(err)
+	}
+	report.Print(os.Stdout)
+}
 
-		// Get all comments for the PR
-		comments, _, err := githubClient.Issues.ListComments(ctx, "openshift", "hypershift", prNumber, nil)
-		if err != nil {
-			log.Printf("Error listing comments for PR %d: %v", prNumber, err)
-			continue
-		}
+// fatal logs an error and exits
+func fatal(err error) {
+	slog.Error(err.Error())
+	os.Exit(1)
+}
 
-		// Find and delete comments from the reporter
-		for _, comment := range comments {
-			if comment.User.Login != nil && *comment.User.Login == currentUser && comment.Body != nil && strings.Contains(*comment.Body, commentMarker) {
-				if dryRun {
-					log.Printf("[DRY RUN] Would delete comment %d from PR %d", *comment.ID, prNumber)
-				} else {
-					_, err := githubClient.Issues.DeleteComment(ctx, "openshift", "hypershift", *comment.ID)
-					if err != nil {
-						log.Printf("Error deleting comment %d from PR %d: %v", *comment.ID, prNumber, err)
-						continue
-					}
-					log.Printf("Successfully deleted comment %d from PR %d", *comment.ID, prNumber)
-				}
-			}
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
 	}
+	return items
 }
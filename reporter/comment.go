@@ -0,0 +1,202 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const testgridURL = "https://testgrid-ci-testgrid.apps.rosa.hypershift-ci-2.1xls.p3.openshiftapps.com/"
+
+// maxFailedTestsPerGroup is how many failed tests are listed for each
+// classification before the rest are summarized.
+const maxFailedTestsPerGroup = 5
+
+//go:embed templates/comment.md.tmpl
+var templateFS embed.FS
+
+// CommentData is the data passed to the comment template.
+type CommentData struct {
+	Results []ResultSection
+}
+
+// ResultSection is the latest result for one test type.
+type ResultSection struct {
+	TestType       string
+	Passed         bool
+	StartedAt      string
+	JobURL         string
+	HistoryURL     string
+	FailedTests    []FailedTest
+	FailureSummary string
+	FailureGroups  []FailureGroup
+}
+
+// FailureGroup is the failed tests sharing a classification. Tests holds at
+// most maxFailedTestsPerGroup entries; More counts the ones left out.
+type FailureGroup struct {
+	Title string
+	Total int
+	Tests []FailedTest
+	More  int
+}
+
+// loadCommentTemplate parses the comment template at path, or the built-in
+// template if path is empty.
+func loadCommentTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		return template.ParseFS(templateFS, "templates/comment.md.tmpl")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading comment template: %v", err)
+	}
+	return template.New(filepath.Base(path)).Parse(string(content))
+}
+
+// getJobIDs returns the reported jobs as written in the job IDs marker:
+// <test type>:<job ID> pairs sorted by test type and separated by commas.
+// The test types can be read back from the marker whatever the template.
+func getJobIDs(results map[string]TestResult) string {
+	var jobIDs []string
+	for testType, result := range results {
+		jobIDs = append(jobIDs, testType+":"+result.ID)
+	}
+	sort.Strings(jobIDs)
+	return strings.Join(jobIDs, ",")
+}
+
+// sameJobIDs reports whether two job IDs markers list the same jobs. Markers
+// written before they held test types list the job IDs only, so test types
+// are ignored rather than editing every existing comment once.
+func sameJobIDs(a, b string) bool {
+	return slices.Equal(markerJobIDs(a), markerJobIDs(b))
+}
+
+// markerJobIDs returns the sorted job IDs listed in a job IDs marker, with or
+// without their test types.
+func markerJobIDs(marker string) []string {
+	var jobIDs []string
+	for _, pair := range strings.Split(marker, ",") {
+		if pair == "" {
+			continue
+		}
+		if _, jobID, ok := strings.Cut(pair, ":"); ok {
+			pair = jobID
+		}
+		jobIDs = append(jobIDs, pair)
+	}
+	sort.Strings(jobIDs)
+	return jobIDs
+}
+
+// formatComment renders the comment body for a PR's results. The markers used
+// to find and compare existing comments are always written ahead of the
+// template output, so overriding the template cannot break updates.
+func formatComment(tmpl *template.Template, results map[string]TestResult) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n%s%s -->\n\n", commentMarker, jobIDsMarker, getJobIDs(results))
+
+	if err := tmpl.Execute(&b, newCommentData(results)); err != nil {
+		return "", fmt.Errorf("error rendering comment: %v", err)
+	}
+	return b.String(), nil
+}
+
+// newCommentData builds the template data, ordering test types as they are
+// configured in testTypes and any others alphabetically after them.
+func newCommentData(results map[string]TestResult) CommentData {
+	var names []string
+	for testType := range results {
+		names = append(names, testType)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ri, rj := testTypeRank(names[i]), testTypeRank(names[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return names[i] < names[j]
+	})
+
+	var data CommentData
+	for _, testType := range names {
+		result := results[testType]
+		data.Results = append(data.Results, ResultSection{
+			TestType:       testType,
+			Passed:         result.Result == "SUCCESS",
+			StartedAt:      result.StartedAt,
+			JobURL:         fmt.Sprintf("%s?job=%s&testName=%s", testgridURL, result.ID, testType),
+			HistoryURL:     fmt.Sprintf("%s?pr=%d&testName=%s", testgridURL, result.PR, testType),
+			FailedTests:    result.FailedTests,
+			FailureSummary: summarizeFailures(result.FailedTests),
+			FailureGroups:  groupFailures(result.FailedTests),
+		})
+	}
+	return data
+}
+
+// testTypeRank returns the position of a test type in testTypes, or
+// len(testTypes) if it isn't configured.
+func testTypeRank(testType string) int {
+	for i, t := range testTypes {
+		if t == testType {
+			return i
+		}
+	}
+	return len(testTypes)
+}
+
+// summarizeFailures returns a one-line count of failed tests per classification.
+func summarizeFailures(failed []FailedTest) string {
+	var parts []string
+	for _, class := range failureClasses {
+		count := 0
+		for _, test := range failed {
+			if test.Classification == class {
+				count++
+			}
+		}
+		if count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count, class))
+		}
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("%d failed", len(failed))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// groupFailures groups failed tests by classification, in reporting order,
+// followed by any tests that could not be classified.
+func groupFailures(failed []FailedTest) []FailureGroup {
+	var groups []FailureGroup
+	for _, class := range append(failureClasses, "") {
+		var tests []FailedTest
+		for _, test := range failed {
+			if test.Classification == class {
+				tests = append(tests, test)
+			}
+		}
+		if len(tests) == 0 {
+			continue
+		}
+
+		title := "Unclassified"
+		if class != "" {
+			title = strings.ToUpper(class[:1]) + class[1:]
+		}
+		group := FailureGroup{Title: title, Total: len(tests), Tests: tests}
+		if len(tests) > maxFailedTestsPerGroup {
+			group.Tests = tests[:maxFailedTestsPerGroup]
+			group.More = len(tests) - maxFailedTestsPerGroup
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
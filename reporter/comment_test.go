@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestFormatComment(t *testing.T) {
+	tmpl, err := loadCommentTemplate("")
+	if err != nil {
+		t.Fatalf("loading template: %v", err)
+	}
+
+	var manyFlaky []FailedTest
+	for _, name := range []string{"TestA", "TestB", "TestC", "TestD", "TestE", "TestF", "TestG"} {
+		manyFlaky = append(manyFlaky, FailedTest{
+			Name:           name,
+			Classification: FailureKnownFlaky,
+			OtherRuns:      20,
+			OtherFailures:  3,
+		})
+	}
+
+	tests := []struct {
+		name    string
+		results map[string]TestResult
+	}{
+		{
+			name: "all_passed",
+			results: map[string]TestResult{
+				"e2e-aks": {ID: "1002", TestName: "e2e-aks", Result: "SUCCESS", StartedAt: "2026-10-01T10:00:00Z", PR: 1234},
+				"e2e-aws": {ID: "1001", TestName: "e2e-aws", Result: "SUCCESS", StartedAt: "2026-10-01T09:00:00Z", PR: 1234},
+			},
+		},
+		{
+			name: "classified_failures",
+			results: map[string]TestResult{
+				"e2e-aws": {
+					ID: "1001", TestName: "e2e-aws", Result: "FAILURE", StartedAt: "2026-10-01T09:00:00Z", PR: 1234,
+					FailedTests: append([]FailedTest{
+						{Name: "TestCreateCluster", Classification: FailureLikelyCausedByPR},
+						{Name: "TestNodePool", Classification: FailureFailingElsewhere, OtherRuns: 10, OtherFailures: 9},
+					}, manyFlaky...),
+				},
+				"e2e-aks": {ID: "1002", TestName: "e2e-aks", Result: "SUCCESS", StartedAt: "2026-10-01T10:00:00Z", PR: 1234},
+			},
+		},
+		{
+			name: "unclassified_failures",
+			results: map[string]TestResult{
+				"e2e-aws": {
+					ID: "1001", TestName: "e2e-aws", Result: "FAILURE", StartedAt: "2026-10-01T09:00:00Z", PR: 1234,
+					FailedTests: []FailedTest{{Name: "TestCreateCluster"}, {Name: "TestUpgrade"}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatComment(tmpl, tt.results)
+			if err != nil {
+				t.Fatalf("formatComment returned error: %v", err)
+			}
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatalf("writing golden file: %v", err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading golden file: %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("comment does not match %s (run with -update to regenerate)\ngot:\n%s\nwant:\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+func TestGetJobIDs(t *testing.T) {
+	results := map[string]TestResult{
+		"e2e-aws": {ID: "1001"},
+		"e2e-aks": {ID: "1002"},
+	}
+	if got, want := getJobIDs(results), "e2e-aks:1002,e2e-aws:1001"; got != want {
+		t.Errorf("getJobIDs = %q, want %q", got, want)
+	}
+}
+
+func TestSameJobIDs(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "e2e-aks:1002,e2e-aws:1001", b: "e2e-aks:1002,e2e-aws:1001", want: true},
+		{a: "e2e-aks:1002,e2e-aws:1001", b: "e2e-aks:1002,e2e-aws:1003"},
+		{a: "e2e-aws:1001", b: "e2e-aks:1002,e2e-aws:1001"},
+		// Markers written before they held test types
+		{a: "1001,1002", b: "e2e-aks:1002,e2e-aws:1001", want: true},
+		{a: "1001", b: "e2e-aks:1002,e2e-aws:1001"},
+		{a: "", b: "", want: true},
+	}
+	for _, tt := range tests {
+		if got := sameJobIDs(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameJobIDs(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// scrapedJobs is the golden file of the scraper's end-to-end test, which
+// holds the jobs it scraped from recorded Prow pages and artifacts.
+var scrapedJobs = filepath.Join("..", "scraper", "testdata", "e2e", "jobs.golden.json")
+
+// TestReportScrapedJobs renders the comment on a PR from the jobs the scraper
+// stored in its end-to-end test.
+func TestReportScrapedJobs(t *testing.T) {
+	data, err := os.ReadFile(scrapedJobs)
+	if err != nil {
+		t.Fatalf("reading jobs: %v", err)
+	}
+	store := &memoryJobStore{}
+	if err := json.Unmarshal(data, &store.jobs); err != nil {
+		t.Fatalf("decoding jobs: %v", err)
+	}
+	tmpl, err := loadCommentTemplate("")
+	if err != nil {
+		t.Fatalf("loading template: %v", err)
+	}
+	reporter := &Reporter{
+		jobs:     store,
+		template: tmpl,
+		now:      func() time.Time { return time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC) },
+	}
+
+	// TestCreateCluster failed in one of the two runs of PR 5030 too, while
+	// TestNodePool passed in both
+	results := reporter.latestResults(context.Background(), 5021)
+	got, err := formatComment(tmpl, results)
+	if err != nil {
+		t.Fatalf("formatComment returned error: %v", err)
+	}
+
+	golden := filepath.Join("testdata", "e2e_comment.golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("comment does not match %s (run with -update to regenerate)\ngot:\n%s\nwant:\n%s", golden, got, want)
+	}
+
+	// Outside of the history window, the failures look new
+	reporter.now = func() time.Time { return time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC) }
+	for _, failed := range reporter.latestResults(context.Background(), 5021)["e2e-aws"].FailedTests {
+		if failed.Classification != FailureLikelyCausedByPR {
+			t.Errorf("%s is %q a month later, want %q", failed.Name, failed.Classification, FailureLikelyCausedByPR)
+		}
+	}
+}
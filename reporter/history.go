@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Failure classifications for a failed test, based on how the same test
+// fared in other PRs' recent runs.
+const (
+	FailureLikelyCausedByPR = "likely caused by this PR"
+	FailureKnownFlaky       = "known flaky"
+	FailureFailingElsewhere = "also failing elsewhere"
+)
+
+const (
+	// failureHistoryDays is how far back to look at other PRs' runs.
+	failureHistoryDays = 7
+	// failingElsewhereRate is the failure rate in other PRs' runs at or above
+	// which a test is considered broken independently of this PR.
+	failingElsewhereRate = 0.5
+)
+
+// failureClasses lists the classifications in the order they are reported.
+var failureClasses = []string{
+	FailureLikelyCausedByPR,
+	FailureKnownFlaky,
+	FailureFailingElsewhere,
+}
+
+// FailedTest is a failed test along with its recent history in other PRs.
+type FailedTest struct {
+	Name           string
+	Classification string
+	OtherRuns      int
+	OtherFailures  int
+}
+
+// testHistory holds run and failure counts for a single test.
+type testHistory struct {
+	Name     string `bson:"_id"`
+	Runs     int    `bson:"runs"`
+	Failures int    `bson:"failures"`
+}
+
+// classifyFailure returns the classification for a test that failed in this
+// PR given how often it ran and failed in other PRs' runs.
+func classifyFailure(otherRuns, otherFailures int) string {
+	if otherRuns == 0 || otherFailures == 0 {
+		return FailureLikelyCausedByPR
+	}
+	if float64(otherFailures)/float64(otherRuns) >= failingElsewhereRate {
+		return FailureFailingElsewhere
+	}
+	return FailureKnownFlaky
+}
+
+// fetchTestHistory returns run and failure counts for the named tests across
+// jobs of the given test type that belong to other PRs and started since
+// since.
+func fetchTestHistory(ctx context.Context, collection *mongo.Collection, testType string, prNumber int, testNames []string, since time.Time) (map[string]testHistory, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"test_name":  testType,
+			"pr":         bson.M{"$ne": prNumber},
+			"started_at": bson.M{"$gte": since.UTC().Format(time.RFC3339)},
+		}}},
+		{{Key: "$project", Value: bson.M{"tests.name": 1, "tests.result": 1}}},
+		{{Key: "$unwind", Value: "$tests"}},
+		{{Key: "$match", Value: bson.M{"tests.name": bson.M{"$in": testNames}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":  "$tests.name",
+			"runs": bson.M{"$sum": 1},
+			"failures": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$eq": bson.A{"$tests.result", "fail"}}, 1, 0},
+			}},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("aggregating test history: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var histories []testHistory
+	if err := cursor.All(ctx, &histories); err != nil {
+		return nil, fmt.Errorf("decoding test history: %v", err)
+	}
+
+	byName := make(map[string]testHistory, len(histories))
+	for _, h := range histories {
+		byName[h.Name] = h
+	}
+	return byName, nil
+}
+
+// classifyFailedTests returns the failed tests of a result, each classified
+// against the same test's runs in other PRs since since. If the history
+// cannot be fetched, the failed tests are returned unclassified along with the
+// error.
+func classifyFailedTests(ctx context.Context, jobs JobStore, result TestResult, since time.Time) ([]FailedTest, error) {
+	var names []string
+	for _, test := range result.Tests {
+		if test.Result == "fail" {
+			names = append(names, test.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	failed := make([]FailedTest, 0, len(names))
+	history, err := jobs.TestHistory(ctx, result.TestName, result.PR, names, since)
+	if err != nil {
+		for _, name := range names {
+			failed = append(failed, FailedTest{Name: name})
+		}
+		return failed, err
+	}
+
+	for _, name := range names {
+		h := history[name]
+		failed = append(failed, FailedTest{
+			Name:           name,
+			Classification: classifyFailure(h.Runs, h.Failures),
+			OtherRuns:      h.Runs,
+			OtherFailures:  h.Failures,
+		})
+	}
+	return failed, nil
+}
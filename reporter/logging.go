@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+type loggerKey struct{}
+
+// setupLogging makes a logger writing to w the default logger. format is text
+// or json, and level is debug, info, warn or error.
+func setupLogging(w io.Writer, format, level string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("invalid log format %q, must be \"text\" or \"json\"", format)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// withLogFields returns a copy of ctx whose logger has the given fields added,
+// so that the log lines of a PR's report can be correlated
+func withLogFields(ctx context.Context, args ...any) context.Context {
+	return context.WithValue(ctx, loggerKey{}, loggerFrom(ctx).With(args...))
+}
+
+// loggerFrom returns the logger carried by ctx, or the default logger
+func loggerFrom(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
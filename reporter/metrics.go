@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// metricsRegistry holds the reporter's metrics. They are served on /metrics
+// in server mode and pushed to a Pushgateway at the end of a batch run.
+var metricsRegistry = prometheus.NewRegistry()
+
+var (
+	commentsTotal = promauto.With(metricsRegistry).NewCounterVec(prometheus.CounterOpts{
+		Name: "reporter_comments_total",
+		Help: "Results comments by action: created, updated or skipped because nothing changed.",
+	}, []string{"action"})
+	githubRateLimitRemaining = promauto.With(metricsRegistry).NewGauge(prometheus.GaugeOpts{
+		Name: "reporter_github_rate_limit_remaining",
+		Help: "Remaining GitHub API requests in the current rate limit window, as of the last response.",
+	})
+)
+
+// metricsHandler serves the reporter's metrics along with Go runtime and
+// process metrics.
+func metricsHandler() http.Handler {
+	metricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{})
+}
+
+// pushMetrics pushes the metrics to the Pushgateway at PUSHGATEWAY_URL, if set.
+func pushMetrics() error {
+	url := os.Getenv("PUSHGATEWAY_URL")
+	if url == "" {
+		return nil
+	}
+	return push.New(url, "ci-reporter").Gatherer(metricsRegistry).Push()
+}
+
+// rateLimitTransport records the GitHub rate limit remaining after each
+// response.
+type rateLimitTransport struct {
+	base http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		githubRateLimitRemaining.Set(float64(remaining))
+	}
+	return resp, nil
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/go-github/v45/github"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	repoOwner = "openshift"
+	repoName  = "hypershift"
+)
+
+// testTypes are the test names whose latest results are reported on each PR.
+var testTypes = []string{"e2e-aws", "e2e-aks"}
+
+// Reporter creates and updates test result comments on GitHub PRs.
+type Reporter struct {
+	github      *github.Client
+	jobs        JobStore
+	state       StateStore
+	currentUser string
+	template    *template.Template
+	dryRun      bool
+	now         func() time.Time
+}
+
+// NewReporter creates a reporter that reads jobs from and keeps its state in
+// db, and posts comments rendered from tmpl as the user authenticated by
+// githubClient.
+func NewReporter(ctx context.Context, githubClient *github.Client, db *mongo.Database, tmpl *template.Template, dryRun bool) (*Reporter, error) {
+	user, _, err := githubClient.Users.Get(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("error getting current user: %v", err)
+	}
+
+	return &Reporter{
+		github:      githubClient,
+		jobs:        mongoJobStore{collection: db.Collection("jobs")},
+		state:       mongoStateStore{collection: db.Collection(stateCollectionName)},
+		currentUser: user.GetLogin(),
+		template:    tmpl,
+		dryRun:      dryRun,
+		now:         time.Now,
+	}, nil
+}
+
+// OpenPRs returns the numbers of all open PRs in the repository.
+func (r *Reporter) OpenPRs(ctx context.Context) ([]int, error) {
+	prs, _, err := r.github.PullRequests.List(ctx, repoOwner, repoName, &github.PullRequestListOptions{
+		State: "open",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var numbers []int
+	for _, pr := range prs {
+		numbers = append(numbers, pr.GetNumber())
+	}
+	return numbers, nil
+}
+
+// ReportAll reports results on every open PR. Errors for individual PRs are
+// logged and do not stop the run.
+func (r *Reporter) ReportAll(ctx context.Context) error {
+	prs, err := r.OpenPRs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, prNumber := range prs {
+		if err := r.ReportPR(ctx, prNumber); err != nil {
+			slog.ErrorContext(ctx, "Error reporting on PR", "pr", prNumber, "error", err)
+		}
+	}
+	return nil
+}
+
+// ReportPR creates or updates the results comment on a single PR. PRs without
+// any stored results are skipped. The comment is found through the stored
+// state, falling back to listing the PR's comments when there is no state or
+// the recorded comment has been deleted.
+func (r *Reporter) ReportPR(ctx context.Context, prNumber int) error {
+	ctx = withLogFields(ctx, "pr", prNumber)
+	logger := loggerFrom(ctx)
+
+	results := r.latestResults(ctx, prNumber)
+	if len(results) == 0 {
+		return nil
+	}
+
+	// Create or update comment
+	commentBody, err := formatComment(r.template, results)
+	if err != nil {
+		return err
+	}
+
+	// Get current job IDs
+	currentJobIDs := getJobIDs(results)
+
+	state, err := r.loadState(ctx, prNumber)
+	if err != nil {
+		logger.Warn("Error loading comment state, listing comments instead", "error", err)
+	}
+
+	if state != nil {
+		// Only update if job IDs or the rendered body have changed, as
+		// when listing comments
+		if sameJobIDs(state.JobIDs, currentJobIDs) && state.ContentHash == contentHash(commentBody) {
+			logger.Info("No changes to report, skipping update")
+			commentsTotal.WithLabelValues("skipped").Inc()
+			return nil
+		}
+
+		updated, err := r.updateComment(ctx, prNumber, state.CommentID, currentJobIDs, commentBody)
+		if err != nil || updated {
+			return err
+		}
+		logger.Info("Comment no longer exists, listing comments", "comment_id", state.CommentID)
+	}
+
+	// Try to find existing comment from current user with our marker
+	existingCommentID, existingJobIDs, existingBody, err := r.findComment(ctx, prNumber)
+	if err != nil {
+		return err
+	}
+
+	// Only update if job IDs or the rendered body have changed
+	if existingCommentID != 0 && sameJobIDs(existingJobIDs, currentJobIDs) && existingBody == commentBody {
+		logger.Info("No changes to report, skipping update")
+		commentsTotal.WithLabelValues("skipped").Inc()
+		if !r.dryRun {
+			return r.saveState(ctx, prNumber, existingCommentID, currentJobIDs, existingBody)
+		}
+		return nil
+	}
+
+	if existingCommentID != 0 {
+		updated, err := r.updateComment(ctx, prNumber, existingCommentID, currentJobIDs, commentBody)
+		if err != nil || updated {
+			return err
+		}
+	}
+
+	return r.createComment(ctx, prNumber, currentJobIDs, commentBody)
+}
+
+// findComment lists a PR's comments, page by page, and returns the ID,
+// reported job IDs and body of the current user's results comment. The ID is
+// 0 if there is none.
+func (r *Reporter) findComment(ctx context.Context, prNumber int) (int64, string, string, error) {
+	opts := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		comments, resp, err := r.github.Issues.ListComments(ctx, repoOwner, repoName, prNumber, opts)
+		if err != nil {
+			return 0, "", "", fmt.Errorf("error listing comments: %v", err)
+		}
+
+		for _, c := range comments {
+			if c.User.Login != nil && *c.User.Login == r.currentUser && c.Body != nil && strings.Contains(*c.Body, commentMarker) {
+				var jobIDs string
+				// Extract job IDs from the comment
+				if idx := strings.Index(*c.Body, jobIDsMarker); idx != -1 {
+					if endIdx := strings.Index((*c.Body)[idx:], " -->"); endIdx != -1 {
+						jobIDs = (*c.Body)[idx+len(jobIDsMarker) : idx+endIdx]
+					}
+				}
+				return *c.ID, jobIDs, *c.Body, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return 0, "", "", nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// updateComment edits an existing comment and records it in the state. It
+// returns false without an error if the comment no longer exists.
+func (r *Reporter) updateComment(ctx context.Context, prNumber int, commentID int64, jobIDs, body string) (bool, error) {
+	if r.dryRun {
+		loggerFrom(ctx).Info("[DRY RUN] Would update existing comment with new results", "comment_id", commentID, "body", body)
+		return true, nil
+	}
+
+	// Update existing comment
+	_, _, err := r.github.Issues.EditComment(ctx, repoOwner, repoName, commentID, &github.IssueComment{Body: &body})
+	if isNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error updating comment: %v", err)
+	}
+	loggerFrom(ctx).Info("Updated comment", "comment_id", commentID)
+	commentsTotal.WithLabelValues("updated").Inc()
+
+	return true, r.saveState(ctx, prNumber, commentID, jobIDs, body)
+}
+
+// createComment posts a new comment and records it in the state.
+func (r *Reporter) createComment(ctx context.Context, prNumber int, jobIDs, body string) error {
+	if r.dryRun {
+		loggerFrom(ctx).Info("[DRY RUN] Would create new comment with results", "body", body)
+		return nil
+	}
+
+	// Create new comment
+	comment, _, err := r.github.Issues.CreateComment(ctx, repoOwner, repoName, prNumber, &github.IssueComment{Body: &body})
+	if err != nil {
+		return fmt.Errorf("error creating comment: %v", err)
+	}
+	loggerFrom(ctx).Info("Created comment", "comment_id", comment.GetID())
+	commentsTotal.WithLabelValues("created").Inc()
+
+	return r.saveState(ctx, prNumber, comment.GetID(), jobIDs, body)
+}
+
+// isNotFound reports whether err is a GitHub 404 response.
+func isNotFound(err error) bool {
+	var ghErr *github.ErrorResponse
+	return errors.As(err, &ghErr) && ghErr.Response != nil && ghErr.Response.StatusCode == http.StatusNotFound
+}
+
+// latestResults finds the latest result of each test type for a PR.
+func (r *Reporter) latestResults(ctx context.Context, prNumber int) map[string]TestResult {
+	results := make(map[string]TestResult)
+
+	for _, testType := range testTypes {
+		job, err := r.jobs.LatestJob(ctx, testType, prNumber)
+		if err != nil {
+			loggerFrom(ctx).Error("Error finding results", "test_name", testType, "error", err)
+			continue
+		}
+		if job == nil {
+			loggerFrom(ctx).Debug("No results found", "test_name", testType)
+			continue
+		}
+		result := *job
+
+		// Compare failures against the same tests in other PRs' runs
+		since := r.now().AddDate(0, 0, -failureHistoryDays)
+		result.FailedTests, err = classifyFailedTests(ctx, r.jobs, result, since)
+		if err != nil {
+			loggerFrom(ctx).Error("Error classifying failures", "test_name", testType, "build_id", job.ID, "error", err)
+		}
+
+		results[testType] = result
+	}
+
+	return results
+}
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v45/github"
+)
+
+const reporterLogin = "ci-reporter"
+
+// fakeComment is a PR comment held by fakeGitHub.
+type fakeComment struct {
+	ID    int64
+	PR    int
+	Login string
+	Body  string
+}
+
+// fakeGitHub serves the issue comment endpoints of openshift/hypershift and
+// records the calls made to them.
+type fakeGitHub struct {
+	mu       sync.Mutex
+	comments []fakeComment
+	nextID   int64
+	calls    []string
+	// perPage caps the comments listed per page, 30 by default as on GitHub
+	perPage int
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/repos/"+repoOwner+"/"+repoName+"/issues/")
+	f.calls = append(f.calls, r.Method+" "+path)
+
+	var comment github.IssueComment
+	if r.Method != http.MethodGet {
+		if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(path, "comments/") && r.Method == http.MethodPatch:
+		id, _ := strconv.ParseInt(strings.TrimPrefix(path, "comments/"), 10, 64)
+		for i := range f.comments {
+			if f.comments[i].ID == id {
+				f.comments[i].Body = comment.GetBody()
+				json.NewEncoder(w).Encode(f.comments[i].issueComment())
+				return
+			}
+		}
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	case strings.HasSuffix(path, "/comments"):
+		pr, _ := strconv.Atoi(strings.TrimSuffix(path, "/comments"))
+		if r.Method == http.MethodPost {
+			f.nextID++
+			created := fakeComment{ID: f.nextID, PR: pr, Login: reporterLogin, Body: comment.GetBody()}
+			f.comments = append(f.comments, created)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(created.issueComment())
+			return
+		}
+		list := []*github.IssueComment{}
+		for _, c := range f.comments {
+			if c.PR == pr {
+				list = append(list, c.issueComment())
+			}
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		page = max(page, 1)
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		if perPage <= 0 {
+			perPage = 30
+		}
+		if f.perPage > 0 {
+			perPage = min(perPage, f.perPage)
+		}
+		start := min((page-1)*perPage, len(list))
+		end := min(start+perPage, len(list))
+		if end < len(list) {
+			next := *r.URL
+			query := next.Query()
+			query.Set("page", strconv.Itoa(page+1))
+			next.RawQuery = query.Encode()
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+		}
+		json.NewEncoder(w).Encode(list[start:end])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (c fakeComment) issueComment() *github.IssueComment {
+	return &github.IssueComment{ID: github.Int64(c.ID), Body: github.String(c.Body), User: &github.User{Login: github.String(c.Login)}}
+}
+
+// body returns the body of a comment, or an empty string if it doesn't exist.
+func (f *fakeGitHub) body(id int64) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.comments {
+		if c.ID == id {
+			return c.Body
+		}
+	}
+	return ""
+}
+
+// storedJob is a job as the scraper stores it.
+type storedJob struct {
+	ID        string `json:"id"`
+	TestName  string `json:"test_name"`
+	Result    string `json:"result"`
+	JobLink   string `json:"job_link"`
+	StartedAt string `json:"started_at"`
+	PR        int    `json:"pr"`
+	Tests     []Test `json:"tests"`
+}
+
+// memoryJobStore is a JobStore of jobs held in memory.
+type memoryJobStore struct {
+	jobs []storedJob
+}
+
+// LatestJob implements JobStore.
+func (s *memoryJobStore) LatestJob(ctx context.Context, testType string, prNumber int) (*TestResult, error) {
+	var latest *storedJob
+	for i, job := range s.jobs {
+		if job.TestName == testType && job.PR == prNumber && (latest == nil || job.StartedAt > latest.StartedAt) {
+			latest = &s.jobs[i]
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	return &TestResult{
+		ID:        latest.ID,
+		TestName:  latest.TestName,
+		Result:    latest.Result,
+		JobLink:   latest.JobLink,
+		StartedAt: latest.StartedAt,
+		PR:        latest.PR,
+		Tests:     latest.Tests,
+	}, nil
+}
+
+// TestHistory implements JobStore.
+func (s *memoryJobStore) TestHistory(ctx context.Context, testType string, prNumber int, testNames []string, since time.Time) (map[string]testHistory, error) {
+	history := make(map[string]testHistory)
+	for _, job := range s.jobs {
+		if job.TestName != testType || job.PR == prNumber || job.StartedAt < since.UTC().Format(time.RFC3339) {
+			continue
+		}
+		for _, test := range job.Tests {
+			if !slices.Contains(testNames, test.Name) {
+				continue
+			}
+			h := history[test.Name]
+			h.Name = test.Name
+			h.Runs++
+			if test.Result == "fail" {
+				h.Failures++
+			}
+			history[test.Name] = h
+		}
+	}
+	return history, nil
+}
+
+// fakeStateStore is a StateStore held in memory.
+type fakeStateStore struct {
+	states  map[int]CommentState
+	loadErr error
+}
+
+func (s *fakeStateStore) Load(ctx context.Context, prNumber int) (*CommentState, error) {
+	if s.loadErr != nil {
+		return nil, s.loadErr
+	}
+	state, ok := s.states[prNumber]
+	if !ok {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+func (s *fakeStateStore) Save(ctx context.Context, state CommentState) error {
+	s.states[state.PR] = state
+	return nil
+}
+
+func (s *fakeStateStore) Delete(ctx context.Context, prNumber int) error {
+	delete(s.states, prNumber)
+	return nil
+}
+
+// markerBody returns a results comment body reporting jobIDs.
+func markerBody(jobIDs, text string) string {
+	return fmt.Sprintf("%s\n%s%s -->\n\n%s", commentMarker, jobIDsMarker, jobIDs, text)
+}
+
+func TestReportPR(t *testing.T) {
+	const pr = 1234
+	// The latest e2e-aws job of the PR is 1002, and TestNodePool failed in
+	// one run of another PR, so the rendered body holds volatile counts
+	jobs := []storedJob{
+		{ID: "1001", TestName: "e2e-aws", Result: "FAILURE", StartedAt: "2026-10-18T09:00:00Z", PR: pr, Tests: []Test{{Name: "TestNodePool", Result: "fail"}}},
+		{ID: "1002", TestName: "e2e-aws", Result: "FAILURE", StartedAt: "2026-10-18T12:00:00Z", PR: pr, Tests: []Test{{Name: "TestNodePool", Result: "fail"}}},
+		{ID: "2001", TestName: "e2e-aws", Result: "FAILURE", StartedAt: "2026-10-18T10:00:00Z", PR: 999, Tests: []Test{{Name: "TestNodePool", Result: "fail"}}},
+	}
+
+	// The body rendered for the PR, left alone when it's already posted
+	tmpl, err := loadCommentTemplate("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	rendered, err := formatComment(tmpl, (&Reporter{
+		jobs: &memoryJobStore{jobs: jobs},
+		now:  func() time.Time { return now },
+	}).latestResults(context.Background(), pr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Comments of other users, filling pages before the reporter's
+	var others []fakeComment
+	for i := range 3 {
+		others = append(others, fakeComment{ID: int64(100 + i), PR: pr, Login: "someone", Body: "/retest"})
+	}
+
+	tests := []struct {
+		name     string
+		comments []fakeComment
+		perPage  int
+		states   map[int]CommentState
+		loadErr  error
+		// wantCalls are the GitHub API calls, relative to the repository's
+		// issues
+		wantCalls   []string
+		wantComment int64 // The comment holding the results afterwards
+		wantUpdated bool  // Whether the comment holds the rendered body
+	}{
+		{
+			name:        "no state or comment",
+			wantCalls:   []string{"GET 1234/comments", "POST 1234/comments"},
+			wantComment: 1,
+			wantUpdated: true,
+		},
+		{
+			name:     "state with the same job IDs and body",
+			comments: []fakeComment{{ID: 7, PR: pr, Login: reporterLogin, Body: rendered}},
+			states: map[int]CommentState{
+				pr: {PR: pr, CommentID: 7, JobIDs: "e2e-aws:1002", ContentHash: contentHash(rendered)},
+			},
+			wantComment: 7,
+			wantUpdated: true,
+		},
+		{
+			name:     "state with the same job IDs and a different body",
+			comments: []fakeComment{{ID: 7, PR: pr, Login: reporterLogin, Body: markerBody("e2e-aws:1002", "old counts")}},
+			states: map[int]CommentState{
+				pr: {PR: pr, CommentID: 7, JobIDs: "e2e-aws:1002", ContentHash: contentHash(markerBody("e2e-aws:1002", "old counts"))},
+			},
+			wantCalls:   []string{"PATCH comments/7"},
+			wantComment: 7,
+			wantUpdated: true,
+		},
+		{
+			name:     "state with older job IDs",
+			comments: []fakeComment{{ID: 7, PR: pr, Login: reporterLogin, Body: markerBody("e2e-aws:1001", "old")}},
+			states: map[int]CommentState{
+				pr: {PR: pr, CommentID: 7, JobIDs: "e2e-aws:1001"},
+			},
+			wantCalls:   []string{"PATCH comments/7"},
+			wantComment: 7,
+			wantUpdated: true,
+		},
+		{
+			name: "state of a deleted comment, found by listing",
+			comments: []fakeComment{
+				{ID: 3, PR: pr, Login: "someone", Body: "LGTM " + commentMarker},
+				{ID: 8, PR: pr, Login: reporterLogin, Body: markerBody("e2e-aws:1001", "old")},
+			},
+			states: map[int]CommentState{
+				pr: {PR: pr, CommentID: 7, JobIDs: "e2e-aws:1001"},
+			},
+			wantCalls:   []string{"PATCH comments/7", "GET 1234/comments", "PATCH comments/8"},
+			wantComment: 8,
+			wantUpdated: true,
+		},
+		{
+			name: "state of a deleted comment, with the same job IDs and body as the listed one",
+			comments: []fakeComment{
+				{ID: 8, PR: pr, Login: reporterLogin, Body: rendered},
+			},
+			states: map[int]CommentState{
+				pr: {PR: pr, CommentID: 7, JobIDs: "e2e-aws:1001"},
+			},
+			wantCalls:   []string{"PATCH comments/7", "GET 1234/comments"},
+			wantComment: 8,
+			wantUpdated: true,
+		},
+		{
+			name: "state of a deleted comment, and no other comment",
+			states: map[int]CommentState{
+				pr: {PR: pr, CommentID: 7, JobIDs: "e2e-aws:1001"},
+			},
+			wantCalls:   []string{"PATCH comments/7", "GET 1234/comments", "POST 1234/comments"},
+			wantComment: 1,
+			wantUpdated: true,
+		},
+		{
+			name:        "no state, comment with the same job IDs and body",
+			comments:    []fakeComment{{ID: 8, PR: pr, Login: reporterLogin, Body: rendered}},
+			wantCalls:   []string{"GET 1234/comments"},
+			wantComment: 8,
+			wantUpdated: true,
+		},
+		{
+			name:        "no state, comment with the same job IDs and a different body",
+			comments:    []fakeComment{{ID: 8, PR: pr, Login: reporterLogin, Body: markerBody("e2e-aws:1002", "old counts")}},
+			wantCalls:   []string{"GET 1234/comments", "PATCH comments/8"},
+			wantComment: 8,
+			wantUpdated: true,
+		},
+		{
+			name:        "no state, comment with the same job IDs and body on a later page",
+			comments:    append(slices.Clone(others), fakeComment{ID: 8, PR: pr, Login: reporterLogin, Body: rendered}),
+			perPage:     2,
+			wantCalls:   []string{"GET 1234/comments", "GET 1234/comments"},
+			wantComment: 8,
+			wantUpdated: true,
+		},
+		{
+			name:        "no state, comment with older job IDs on a later page",
+			comments:    append(slices.Clone(others), fakeComment{ID: 8, PR: pr, Login: reporterLogin, Body: markerBody("e2e-aws:1001", "old")}),
+			perPage:     2,
+			wantCalls:   []string{"GET 1234/comments", "GET 1234/comments", "PATCH comments/8"},
+			wantComment: 8,
+			wantUpdated: true,
+		},
+		{
+			// Markers were written without test types before, and such
+			// comments are rewritten in the new format
+			name:        "no state, comment with the same job IDs without test types",
+			comments:    []fakeComment{{ID: 8, PR: pr, Login: reporterLogin, Body: strings.Replace(rendered, "e2e-aws:1002", "1002", 1)}},
+			wantCalls:   []string{"GET 1234/comments", "PATCH comments/8"},
+			wantComment: 8,
+			wantUpdated: true,
+		},
+		{
+			name:        "state not loaded",
+			comments:    []fakeComment{{ID: 8, PR: pr, Login: reporterLogin, Body: markerBody("e2e-aws:1001", "old")}},
+			loadErr:     errors.New("connection refused"),
+			wantCalls:   []string{"GET 1234/comments", "PATCH comments/8"},
+			wantComment: 8,
+			wantUpdated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gh := &fakeGitHub{comments: tt.comments, perPage: tt.perPage}
+			server := httptest.NewServer(gh)
+			defer server.Close()
+			client := github.NewClient(nil)
+			client.BaseURL, _ = url.Parse(server.URL + "/")
+
+			states := tt.states
+			if states == nil {
+				states = make(map[int]CommentState)
+			}
+			store := &fakeStateStore{states: states, loadErr: tt.loadErr}
+			r := &Reporter{
+				github:      client,
+				jobs:        &memoryJobStore{jobs: jobs},
+				state:       store,
+				currentUser: reporterLogin,
+				template:    tmpl,
+				now:         func() time.Time { return now },
+			}
+
+			if err := r.ReportPR(context.Background(), pr); err != nil {
+				t.Fatalf("ReportPR returned error: %v", err)
+			}
+
+			if strings.Join(gh.calls, ", ") != strings.Join(tt.wantCalls, ", ") {
+				t.Errorf("GitHub calls = %q, want %q", gh.calls, tt.wantCalls)
+			}
+			body := gh.body(tt.wantComment)
+			// With or without its test type
+			if !strings.Contains(body, "1002 -->") {
+				t.Errorf("comment %d = %q, want it to report job 1002", tt.wantComment, body)
+			}
+			if updated := strings.Contains(body, "TestNodePool"); updated != tt.wantUpdated {
+				t.Errorf("comment %d updated = %v, want %v", tt.wantComment, updated, tt.wantUpdated)
+			}
+
+			// The state points at the comment holding the results
+			state, ok := store.states[pr]
+			switch {
+			case !ok:
+				t.Error("no state was saved")
+			case state.CommentID != tt.wantComment || state.JobIDs != "e2e-aws:1002":
+				t.Errorf("state = comment %d with job IDs %q, want comment %d with e2e-aws:1002", state.CommentID, state.JobIDs, tt.wantComment)
+			case tt.wantCalls != nil && (state.ContentHash != contentHash(body) || !state.UpdatedAt.Equal(now)):
+				t.Errorf("state = hash %s at %s, want the hash of the comment's body at %s", state.ContentHash, state.UpdatedAt, now)
+			}
+		})
+	}
+}
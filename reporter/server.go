@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v45/github"
+)
+
+// JobNotification is the body the scraper posts to the internal endpoint
+// after storing a job.
+type JobNotification struct {
+	JobID    string `json:"job_id"`
+	TestName string `json:"test_name"`
+	PR       int    `json:"pr"`
+}
+
+// Server runs the reporter as a long-lived service that reports on single PRs
+// as webhook events and scraper notifications arrive.
+type Server struct {
+	reporter      *Reporter
+	webhookSecret []byte
+	internalToken string
+	// allowAnonymous lets anyone call the internal endpoint when there is
+	// no internalToken
+	allowAnonymous bool
+	queue          *prQueue
+}
+
+// Timeouts of the server's connections, so that slow or idle clients can't
+// hold them open
+const (
+	readHeaderTimeout = 10 * time.Second
+	readTimeout       = 30 * time.Second
+	writeTimeout      = 30 * time.Second
+	idleTimeout       = 2 * time.Minute
+	// shutdownTimeout is how long requests in flight are given to finish
+	// when the server shuts down
+	shutdownTimeout = 10 * time.Second
+)
+
+// NewServer creates a server. Webhook payloads are validated against
+// webhookSecret, and requests to the internal endpoint must present
+// internalToken as a bearer token. Without a token, the internal endpoint is
+// only served if allowAnonymous is set.
+func NewServer(reporter *Reporter, webhookSecret []byte, internalToken string, allowAnonymous bool) *Server {
+	return &Server{
+		reporter:       reporter,
+		webhookSecret:  webhookSecret,
+		internalToken:  internalToken,
+		allowAnonymous: allowAnonymous,
+		queue:          newPRQueue(),
+	}
+}
+
+// Run serves HTTP on addr until the server fails or ctx is done, when it shuts
+// the server down. If reconcileInterval is non-zero, all open PRs are queued
+// for reporting at that interval.
+func (s *Server) Run(ctx context.Context, addr string, reconcileInterval time.Duration) error {
+	if len(s.webhookSecret) == 0 {
+		return errors.New("GITHUB_WEBHOOK_SECRET environment variable is required in server mode")
+	}
+	if s.internalToken == "" && !s.allowAnonymous {
+		return errors.New("REPORTER_INTERNAL_TOKEN environment variable is required in server mode, unless --allow-anonymous-notifications is set")
+	}
+
+	go s.worker(ctx)
+	if reconcileInterval > 0 {
+		go s.reconcile(ctx, reconcileInterval)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhook", s.handleWebhook)
+	mux.HandleFunc("/internal/jobs", s.handleJobNotification)
+	mux.Handle("/metrics", metricsHandler())
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+	slog.Info("Starting reporter server", "addr", addr)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down reporter server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// handleWebhook handles GitHub pull_request events.
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := github.ValidatePayload(r, s.webhookSecret)
+	if err != nil {
+		slog.Warn("Rejected webhook", "error", err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	event, err := github.ParseWebHook(github.WebHookType(r), payload)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error parsing webhook: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	switch e := event.(type) {
+	case *github.PullRequestEvent:
+		prNumber := e.GetPullRequest().GetNumber()
+		switch e.GetAction() {
+		case "opened", "reopened", "synchronize":
+			slog.Info("Received pull_request event", "action", e.GetAction(), "pr", prNumber)
+			s.queue.Add(prNumber)
+		case "closed":
+			// Let the worker finish any report in progress first, so it
+			// doesn't report on the PR after it was closed
+			slog.Info("Received pull_request event", "action", e.GetAction(), "pr", prNumber)
+			s.queue.Close(prNumber)
+		}
+	case *github.PingEvent:
+		slog.Info("Received webhook ping")
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleJobNotification handles notifications from the scraper that a job
+// has been stored.
+func (s *Server) handleJobNotification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.internalToken != "" || !s.allowAnonymous {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || s.internalToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.internalToken)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	var notification JobNotification
+	if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+		http.Error(w, fmt.Sprintf("error decoding notification: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Jobs that don't belong to a PR have nothing to report on
+	if notification.PR > 0 {
+		slog.Info("Received job", "build_id", notification.JobID, "test_name", notification.TestName, "pr", notification.PR)
+		s.queue.Add(notification.PR)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// worker reports on queued PRs one at a time so that concurrent events for
+// the same PR cannot create duplicate comments.
+func (s *Server) worker(ctx context.Context) {
+	for {
+		prNumber, closed, ok := s.queue.Next(ctx)
+		if !ok {
+			return
+		}
+		if closed {
+			slog.InfoContext(ctx, "PR closed, no further updates will be made", "pr", prNumber)
+			if err := s.reporter.ForgetPR(ctx, prNumber); err != nil {
+				slog.ErrorContext(ctx, "Error removing comment state", "pr", prNumber, "error", err)
+			}
+			continue
+		}
+		start := time.Now()
+		if err := s.reporter.ReportPR(ctx, prNumber); err != nil {
+			slog.ErrorContext(ctx, "Error reporting on PR", "pr", prNumber, "error", err)
+			continue
+		}
+		slog.DebugContext(ctx, "Reported on PR", "pr", prNumber, "duration", time.Since(start))
+	}
+}
+
+// reconcile periodically queues every open PR, catching up on any events
+// that were missed.
+func (s *Server) reconcile(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			prs, err := s.reporter.OpenPRs(ctx)
+			if err != nil {
+				slog.Error("Error listing open PRs for reconciliation", "error", err)
+				continue
+			}
+			slog.Info("Reconciling open PRs", "count", len(prs))
+			for _, prNumber := range prs {
+				s.queue.Add(prNumber)
+			}
+		}
+	}
+}
+
+// prQueue is a FIFO of PR numbers in which each PR appears at most once,
+// either to be reported on or, once closed, to stop being reported on.
+type prQueue struct {
+	mu      sync.Mutex
+	pending []int
+	queued  map[int]bool // Whether each pending PR was closed
+	ready   chan struct{}
+}
+
+func newPRQueue() *prQueue {
+	return &prQueue{
+		queued: make(map[int]bool),
+		ready:  make(chan struct{}, 1),
+	}
+}
+
+// Add queues a PR unless it is already waiting to be reported. A closed PR
+// waiting in the queue is reported on again, as it was reopened.
+func (q *prQueue) Add(prNumber int) {
+	q.push(prNumber, false)
+}
+
+// Close queues a closed PR, replacing any report it was waiting for.
+func (q *prQueue) Close(prNumber int) {
+	q.push(prNumber, true)
+}
+
+func (q *prQueue) push(prNumber int, closed bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.queued[prNumber]; !ok {
+		q.pending = append(q.pending, prNumber)
+	}
+	q.queued[prNumber] = closed
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Next blocks until a PR is queued or ctx is done, and reports whether the PR
+// was closed.
+func (q *prQueue) Next(ctx context.Context) (int, bool, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.pending) > 0 {
+			prNumber := q.pending[0]
+			q.pending = q.pending[1:]
+			closed := q.queued[prNumber]
+			delete(q.queued, prNumber)
+			q.mu.Unlock()
+			return prNumber, closed, true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return 0, false, false
+		case <-q.ready:
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "webhook-secret"
+
+// webhookRequest returns a webhook delivery of an event, signed with secret.
+func webhookRequest(event, payload, secret string) *http.Request {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+// queued returns the PRs waiting in a server's queue.
+func queued(s *Server) []int {
+	s.queue.mu.Lock()
+	defer s.queue.mu.Unlock()
+	return slices.Clone(s.queue.pending)
+}
+
+func TestHandleWebhook(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        *http.Request
+		wantStatus int
+		wantQueued []int
+	}{
+		{
+			name:       "synchronize",
+			req:        webhookRequest("pull_request", `{"action": "synchronize", "pull_request": {"number": 1234}}`, testWebhookSecret),
+			wantStatus: http.StatusAccepted,
+			wantQueued: []int{1234},
+		},
+		{
+			name:       "opened",
+			req:        webhookRequest("pull_request", `{"action": "opened", "pull_request": {"number": 1234}}`, testWebhookSecret),
+			wantStatus: http.StatusAccepted,
+			wantQueued: []int{1234},
+		},
+		{
+			name:       "bad signature",
+			req:        webhookRequest("pull_request", `{"action": "synchronize", "pull_request": {"number": 1234}}`, "other-secret"),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "missing signature",
+			req: func() *http.Request {
+				req := webhookRequest("pull_request", `{"action": "synchronize", "pull_request": {"number": 1234}}`, testWebhookSecret)
+				req.Header.Del("X-Hub-Signature-256")
+				return req
+			}(),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "ignored action",
+			req:        webhookRequest("pull_request", `{"action": "labeled", "pull_request": {"number": 1234}}`, testWebhookSecret),
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "ignored event",
+			req:        webhookRequest("issue_comment", `{"action": "created", "issue": {"number": 1234}}`, testWebhookSecret),
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "ping",
+			req:        webhookRequest("ping", `{"zen": "Keep it logically awesome."}`, testWebhookSecret),
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "GET",
+			req:        httptest.NewRequest(http.MethodGet, "/webhook", nil),
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(nil, []byte(testWebhookSecret), "token", false)
+			recorder := httptest.NewRecorder()
+			s.handleWebhook(recorder, tt.req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if got := queued(s); !slices.Equal(got, tt.wantQueued) {
+				t.Errorf("queued PRs = %v, want %v", got, tt.wantQueued)
+			}
+		})
+	}
+}
+
+func TestHandleJobNotification(t *testing.T) {
+	const body = `{"job_id": "1979400000000000004", "test_name": "e2e-aws", "pr": 5021}`
+	tests := []struct {
+		name           string
+		token          string
+		allowAnonymous bool
+		authorization  string
+		body           string
+		wantStatus     int
+		wantQueued     []int
+	}{
+		{
+			name:          "valid token",
+			token:         "token",
+			authorization: "Bearer token",
+			body:          body,
+			wantStatus:    http.StatusAccepted,
+			wantQueued:    []int{5021},
+		},
+		{
+			name:          "wrong token",
+			token:         "token",
+			authorization: "Bearer other",
+			body:          body,
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:          "token without Bearer",
+			token:         "token",
+			authorization: "token",
+			body:          body,
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:       "missing token",
+			token:      "token",
+			body:       body,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "no token configured",
+			body:       body,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "anonymous allowed",
+			allowAnonymous: true,
+			body:           body,
+			wantStatus:     http.StatusAccepted,
+			wantQueued:     []int{5021},
+		},
+		{
+			name:           "token required when configured, even if anonymous is allowed",
+			token:          "token",
+			allowAnonymous: true,
+			body:           body,
+			wantStatus:     http.StatusUnauthorized,
+		},
+		{
+			name:          "job without PR",
+			token:         "token",
+			authorization: "Bearer token",
+			body:          `{"job_id": "1979400000000000004", "test_name": "e2e-aws"}`,
+			wantStatus:    http.StatusAccepted,
+		},
+		{
+			name:          "invalid body",
+			token:         "token",
+			authorization: "Bearer token",
+			body:          `{"pr": "5021"`,
+			wantStatus:    http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(nil, []byte(testWebhookSecret), tt.token, tt.allowAnonymous)
+			req := httptest.NewRequest(http.MethodPost, "/internal/jobs", strings.NewReader(tt.body))
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			s.handleJobNotification(recorder, req)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if got := queued(s); !slices.Equal(got, tt.wantQueued) {
+				t.Errorf("queued PRs = %v, want %v", got, tt.wantQueued)
+			}
+		})
+	}
+}
+
+func TestRunRequiresSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		server  *Server
+		wantErr string
+	}{
+		{
+			name:    "no webhook secret",
+			server:  NewServer(nil, nil, "token", false),
+			wantErr: "GITHUB_WEBHOOK_SECRET",
+		},
+		{
+			name:    "no internal token",
+			server:  NewServer(nil, []byte(testWebhookSecret), "", false),
+			wantErr: "REPORTER_INTERNAL_TOKEN",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.server.Run(context.Background(), "127.0.0.1:0", 0)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Run returned %v, want an error about %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunShutsDown(t *testing.T) {
+	s := NewServer(nil, []byte(testWebhookSecret), "token", false)
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.Run(ctx, "127.0.0.1:0", 0)
+	}()
+
+	cancel()
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Errorf("Run returned %v after ctx was done, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't return after ctx was done")
+	}
+}
+
+func TestPRQueueDeduplicates(t *testing.T) {
+	q := newPRQueue()
+	for _, pr := range []int{1, 2, 1, 3, 2} {
+		q.Add(pr)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var got []int
+	for range 3 {
+		pr, _, ok := q.Next(ctx)
+		if !ok {
+			t.Fatal("Next returned no PR")
+		}
+		got = append(got, pr)
+	}
+	if want := []int{1, 2, 3}; !slices.Equal(got, want) {
+		t.Errorf("PRs = %v, want %v", got, want)
+	}
+
+	// A PR that was taken off the queue can be queued again
+	q.Add(1)
+	if pr, _, ok := q.Next(ctx); !ok || pr != 1 {
+		t.Errorf("Next = %d, %v, want 1 queued again", pr, ok)
+	}
+
+	// Next returns once ctx is done if nothing is queued
+	empty, cancelEmpty := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelEmpty()
+	if pr, _, ok := q.Next(empty); ok {
+		t.Errorf("Next of an empty queue returned %d", pr)
+	}
+}
+
+func TestPRQueueClose(t *testing.T) {
+	s := NewServer(nil, []byte(testWebhookSecret), "token", false)
+	for _, action := range []string{"synchronize", "closed"} {
+		payload := `{"action": "` + action + `", "pull_request": {"number": 1234}}`
+		s.handleWebhook(httptest.NewRecorder(), webhookRequest("pull_request", payload, testWebhookSecret))
+	}
+	s.queue.Add(5678)
+
+	// The PR closed while queued is no longer reported on, and keeps its
+	// place in the queue
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if pr, closed, ok := s.queue.Next(ctx); !ok || pr != 1234 || !closed {
+		t.Errorf("Next = %d, %v, %v, want 1234 closed", pr, closed, ok)
+	}
+	if pr, closed, ok := s.queue.Next(ctx); !ok || pr != 5678 || closed {
+		t.Errorf("Next = %d, %v, %v, want 5678 to report on", pr, closed, ok)
+	}
+
+	// A PR reopened before the worker got to it is reported on
+	s.queue.Close(1234)
+	s.queue.Add(1234)
+	if pr, closed, ok := s.queue.Next(ctx); !ok || pr != 1234 || closed {
+		t.Errorf("Next = %d, %v, %v, want reopened 1234 to report on", pr, closed, ok)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// stateCollectionName is the collection holding one CommentState per PR.
+const stateCollectionName = "reporter_state"
+
+// CommentState records the comment the reporter last posted on a PR, so that
+// later runs don't have to list and parse the PR's comments to find it.
+type CommentState struct {
+	PR        int    `bson:"_id"`
+	CommentID int64  `bson:"comment_id"`
+	JobIDs    string `bson:"job_ids"`
+	// ContentHash identifies the body that was posted, so that the comment
+	// is updated when the body changes without new jobs, e.g. as other PRs'
+	// runs change how the failures are classified.
+	ContentHash string    `bson:"content_hash"`
+	UpdatedAt   time.Time `bson:"updated_at"`
+}
+
+// contentHash returns the hex-encoded SHA-256 of a comment body.
+func contentHash(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+// StateStore keeps one CommentState per PR.
+type StateStore interface {
+	// Load returns the state of a PR, or nil if there is none.
+	Load(ctx context.Context, prNumber int) (*CommentState, error)
+	// Save replaces the state of a PR.
+	Save(ctx context.Context, state CommentState) error
+	// Delete removes the state of a PR.
+	Delete(ctx context.Context, prNumber int) error
+}
+
+// mongoStateStore is the StateStore of a MongoDB collection.
+type mongoStateStore struct {
+	collection *mongo.Collection
+}
+
+// Load implements StateStore.
+func (s mongoStateStore) Load(ctx context.Context, prNumber int) (*CommentState, error) {
+	var state CommentState
+	err := s.collection.FindOne(ctx, bson.M{"_id": prNumber}).Decode(&state)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Save implements StateStore.
+func (s mongoStateStore) Save(ctx context.Context, state CommentState) error {
+	_, err := s.collection.ReplaceOne(ctx, bson.M{"_id": state.PR}, state, options.Replace().SetUpsert(true))
+	return err
+}
+
+// Delete implements StateStore.
+func (s mongoStateStore) Delete(ctx context.Context, prNumber int) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": prNumber})
+	return err
+}
+
+// loadState returns the stored state for a PR, or nil if there is none.
+func (r *Reporter) loadState(ctx context.Context, prNumber int) (*CommentState, error) {
+	return r.state.Load(ctx, prNumber)
+}
+
+// saveState records the comment posted on a PR.
+func (r *Reporter) saveState(ctx context.Context, prNumber int, commentID int64, jobIDs, body string) error {
+	return r.state.Save(ctx, CommentState{
+		PR:          prNumber,
+		CommentID:   commentID,
+		JobIDs:      jobIDs,
+		ContentHash: contentHash(body),
+		UpdatedAt:   r.now().UTC(),
+	})
+}
+
+// ForgetPR removes the stored state for a PR.
+func (r *Reporter) ForgetPR(ctx context.Context, prNumber int) error {
+	return r.state.Delete(ctx, prNumber)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JobStore retrieves the jobs stored by the scraper.
+type JobStore interface {
+	// LatestJob returns the latest job of a test type for a PR, or nil if
+	// there is none.
+	LatestJob(ctx context.Context, testType string, prNumber int) (*TestResult, error)
+	// TestHistory returns run and failure counts for the named tests across
+	// the jobs of a test type that belong to other PRs and started since
+	// since.
+	TestHistory(ctx context.Context, testType string, prNumber int, testNames []string, since time.Time) (map[string]testHistory, error)
+}
+
+// mongoJobStore is the JobStore of the scraper's jobs collection.
+type mongoJobStore struct {
+	collection *mongo.Collection
+}
+
+// LatestJob implements JobStore.
+func (s mongoJobStore) LatestJob(ctx context.Context, testType string, prNumber int) (*TestResult, error) {
+	var job struct {
+		ID        string `bson:"_id"`
+		TestName  string `bson:"test_name"`
+		Result    string `bson:"result"`
+		JobLink   string `bson:"job_link"`
+		StartedAt string `bson:"started_at"`
+		PR        int    `bson:"pr"`
+		Tests     []Test `bson:"tests"`
+	}
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "started_at", Value: -1}})
+	err := s.collection.FindOne(ctx, bson.M{
+		"test_name": testType,
+		"pr":        prNumber,
+	}, opts).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &TestResult{
+		ID:        job.ID,
+		TestName:  job.TestName,
+		Result:    job.Result,
+		JobLink:   job.JobLink,
+		StartedAt: job.StartedAt,
+		PR:        job.PR,
+		Tests:     job.Tests,
+	}, nil
+}
+
+// TestHistory implements JobStore.
+func (s mongoJobStore) TestHistory(ctx context.Context, testType string, prNumber int, testNames []string, since time.Time) (map[string]testHistory, error) {
+	return fetchTestHistory(ctx, s.collection, testType, prNumber, testNames, since)
+}
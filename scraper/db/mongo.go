@@ -44,16 +44,43 @@ func Connect() (*mongo.Client, error) {
 	return client, nil
 }
 
-func JobExists(ctx context.Context, collection *mongo.Collection, jobID string) (bool, error) {
+// MongoRepository stores jobs in a MongoDB collection
+type MongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoRepository returns a repository of the jobs in collection
+func NewMongoRepository(collection *mongo.Collection) *MongoRepository {
+	return &MongoRepository{collection: collection}
+}
+
+func (r *MongoRepository) JobExists(ctx context.Context, jobID string) (bool, error) {
 	var result types.Job
-	err := collection.FindOne(ctx, bson.M{"_id": jobID}).Decode(&result)
+	err := r.collection.FindOne(ctx, bson.M{"_id": jobID}).Decode(&result)
 	if err == mongo.ErrNoDocuments {
 		return false, nil
 	}
 	return err == nil, err
 }
 
-func InsertJob(ctx context.Context, collection *mongo.Collection, job *types.Job) error {
-	_, err := collection.InsertOne(ctx, job)
+func (r *MongoRepository) InsertJob(ctx context.Context, job *types.Job) error {
+	_, err := r.collection.InsertOne(ctx, job)
+	return err
+}
+
+// LogsTextIndex is the name of the text index over test logs used by the UI's
+// log search
+const LogsTextIndex = "tests_logs_text"
+
+// EnsureIndexes creates the indexes the UI relies on, if they don't exist yet.
+// The logs text index uses no language so that log tokens aren't stemmed and
+// no stop words are dropped.
+func (r *MongoRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "tests.logs", Value: "text"}},
+		Options: options.Index().
+			SetName(LogsTextIndex).
+			SetDefaultLanguage("none"),
+	})
 	return err
 }
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/hypershift-community/ci-testgrid/scraper/types"
+)
+
+// Repository stores scraped jobs
+type Repository interface {
+	// JobExists reports whether a job is already stored
+	JobExists(ctx context.Context, jobID string) (bool, error)
+	// InsertJob stores a job
+	InsertJob(ctx context.Context, job *types.Job) error
+	// EnsureIndexes creates the indexes the UI relies on
+	EnsureIndexes(ctx context.Context) error
+}
+
+// MemoryRepository stores jobs in memory, standing in for MongoDB in tests
+type MemoryRepository struct {
+	mu   sync.Mutex
+	jobs map[string]types.Job
+}
+
+// NewMemoryRepository returns an empty in-memory repository
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{jobs: make(map[string]types.Job)}
+}
+
+func (r *MemoryRepository) JobExists(ctx context.Context, jobID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.jobs[jobID]
+	return ok, nil
+}
+
+func (r *MemoryRepository) InsertJob(ctx context.Context, job *types.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.jobs[job.ID]; ok {
+		return fmt.Errorf("job %s already exists", job.ID)
+	}
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *MemoryRepository) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
+
+// Jobs returns the stored jobs, newest first
+func (r *MemoryRepository) Jobs() []types.Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	jobs := make([]types.Job, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].StartedAt != jobs[j].StartedAt {
+			return jobs[i].StartedAt > jobs[j].StartedAt
+		}
+		return jobs[i].ID > jobs[j].ID
+	})
+	return jobs
+}
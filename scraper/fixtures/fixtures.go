@@ -0,0 +1,93 @@
+// Package fixtures serves recorded Prow job history pages, build logs and
+// artifacts the way Prow and gcsweb do, so the scraper can be tested offline.
+package fixtures
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Prow serves the pages recorded in dir. A page is stored as <path>.html, or
+// <path>@<query>.html if it was requested with a query, e.g. the older runs
+// of a job history.
+func Prow(dir string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Path
+		if r.URL.RawQuery != "" {
+			name += "@" + r.URL.RawQuery
+		}
+		path := filepath.Join(dir, filepath.FromSlash(name)+".html")
+		if _, err := os.Stat(path); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		http.ServeFile(w, r, path)
+	})
+}
+
+// GCSWeb serves dir at /gcs/: files as they are, and directories as HTML
+// listings linking to their entries, like gcsweb does for a bucket
+func GCSWeb(dir string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rel, ok := strings.CutPrefix(r.URL.Path, "/gcs/")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		info, err := os.Stat(path)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if !info.IsDir() {
+			http.ServeFile(w, r, path)
+			return
+		}
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<!doctype html>\n<html><head><title>GCS browser: %s</title></head><body>\n", html.EscapeString(rel))
+		fmt.Fprintf(w, "<ul class=\"resource-grid\">\n<li class=\"pure-g\"><a href=\"%s\"><img src=\"/icons/back.png\"> ..</a></li>\n", html.EscapeString(parentDir(r.URL.Path)))
+		for _, entry := range entries {
+			name := entry.Name()
+			icon := "file"
+			if entry.IsDir() {
+				name += "/"
+				icon = "dir"
+			}
+			fmt.Fprintf(w, "<li class=\"pure-g grid-row\"><a href=\"%s%s\"><img src=\"/icons/%s.png\"> %s</a></li>\n",
+				html.EscapeString(r.URL.Path), html.EscapeString(name), icon, html.EscapeString(name))
+		}
+		fmt.Fprintf(w, "</ul>\n</body></html>\n")
+	})
+}
+
+// parentDir returns the parent directory of a directory's URL path
+func parentDir(path string) string {
+	path = strings.TrimSuffix(path, "/")
+	return path[:strings.LastIndex(path, "/")+1]
+}
+
+// Handler serves the recordings in dir: Prow pages from dir/prow and the GCS
+// bucket from dir/gcs at /gcs/. Tests serve it with httptest and pass the
+// server's URL to the scraper as both the Prow and the gcsweb URL.
+func Handler(dir string) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/", Prow(filepath.Join(dir, "prow")))
+	mux.Handle("/gcs/", GCSWeb(filepath.Join(dir, "gcs")))
+	return mux
+}
@@ -0,0 +1,357 @@
+// Package httpclient provides the HTTP client shared by the scraper's
+// packages. It validates status codes, retries transient failures with
+// exponential backoff, limits the request rate per host and can cache the
+// bodies of immutable resources, such as the artifacts of finished builds, on
+// disk.
+package httpclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hypershift-community/ci-testgrid/scraper/logging"
+)
+
+// Config configures a Client
+type Config struct {
+	// DialTimeout limits connecting to a host
+	DialTimeout time.Duration
+	// ResponseHeaderTimeout limits waiting for the response headers once a
+	// request is sent. Reading the body is only limited by the request's
+	// context, so that large artifacts can be downloaded slowly.
+	ResponseHeaderTimeout time.Duration
+	// MaxRetries is how many times a request is retried after a network
+	// error, a 5xx or a 429 response
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry, doubled for each
+	// further retry up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// RequestsPerSecond limits the request rate to each host, 0 disables
+	RequestsPerSecond float64
+	UserAgent         string
+	// CacheDir is where the bodies of immutable resources are cached. Empty
+	// disables the cache.
+	CacheDir string
+}
+
+// DefaultConfig returns the configuration of Default
+func DefaultConfig() Config {
+	return Config{
+		DialTimeout:           30 * time.Second,
+		ResponseHeaderTimeout: 2 * time.Minute,
+		MaxRetries:            3,
+		InitialBackoff:        time.Second,
+		MaxBackoff:            30 * time.Second,
+		RequestsPerSecond:     10,
+		UserAgent:             "ci-testgrid-scraper",
+	}
+}
+
+// Default is the client used by the scraper's packages when their context
+// carries none
+var Default = New(DefaultConfig())
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying client, which the scraper's
+// packages use instead of Default
+func NewContext(ctx context.Context, client *Client) context.Context {
+	return context.WithValue(ctx, contextKey{}, client)
+}
+
+// FromContext returns the client carried by ctx, or Default
+func FromContext(ctx context.Context) *Client {
+	if client, ok := ctx.Value(contextKey{}).(*Client); ok {
+		return client
+	}
+	return Default
+}
+
+// StatusError is returned for responses with a status code other than 200
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("GET %s returned status %d", e.URL, e.StatusCode)
+}
+
+// IsNotFound reports whether err is a 404 response
+func IsNotFound(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// Client fetches resources over HTTP
+type Client struct {
+	config Config
+	http   *http.Client
+
+	mu       sync.Mutex
+	limiters map[string]*hostLimiter
+}
+
+// New returns a client with the given configuration
+func New(config Config) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   config.DialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.ResponseHeaderTimeout = config.ResponseHeaderTimeout
+	return &Client{
+		config:   config,
+		http:     &http.Client{Transport: transport},
+		limiters: make(map[string]*hostLimiter),
+	}
+}
+
+// Open fetches a resource and returns its body, which the caller must close
+func (c *Client) Open(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	return c.get(ctx, rawURL)
+}
+
+// OpenCached is like Open for resources that never change once they exist.
+// If the cache is enabled, a cached body is returned without a request, and a
+// fetched body is cached once it has been read completely.
+func (c *Client) OpenCached(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	if c.config.CacheDir == "" {
+		return c.get(ctx, rawURL)
+	}
+
+	path := c.cachePath(rawURL)
+	if f, err := os.Open(path); err == nil {
+		logging.FromContext(ctx).Debug("Serving response from cache", logging.URL, rawURL)
+		return f, nil
+	}
+
+	body, err := c.get(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(c.config.CacheDir, 0o755); err != nil {
+		logging.FromContext(ctx).Warn("Error creating cache directory", logging.Error, err)
+		return body, nil
+	}
+	tmp, err := os.CreateTemp(c.config.CacheDir, ".tmp-*")
+	if err != nil {
+		logging.FromContext(ctx).Warn("Error creating cache file", logging.Error, err)
+		return body, nil
+	}
+	return &cachingBody{ReadCloser: body, tmp: tmp, path: path}, nil
+}
+
+// Get fetches a resource and returns its body
+func (c *Client) Get(ctx context.Context, rawURL string) ([]byte, error) {
+	body, err := c.Open(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// GetCached is like Get for resources that never change once they exist
+func (c *Client) GetCached(ctx context.Context, rawURL string) ([]byte, error) {
+	body, err := c.OpenCached(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// get sends a GET request, retrying transient failures, and returns the body
+// of a 200 response
+func (c *Client) get(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	logger := logging.FromContext(ctx)
+
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter(u.Host).wait(ctx); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		if c.config.UserAgent != "" {
+			req.Header.Set("User-Agent", c.config.UserAgent)
+		}
+
+		start := time.Now()
+		resp, err := c.http.Do(req)
+		var retryAfter time.Duration
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+		case resp.StatusCode == http.StatusOK:
+			logger.Debug("Fetched", logging.URL, rawURL, logging.Duration, time.Since(start))
+			return resp.Body, nil
+		default:
+			resp.Body.Close()
+			err = &StatusError{URL: rawURL, StatusCode: resp.StatusCode}
+			if !retryable(resp.StatusCode) {
+				return nil, err
+			}
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		}
+
+		if attempt >= c.config.MaxRetries {
+			return nil, err
+		}
+		delay := max(c.backoff(attempt), retryAfter)
+		logger.Warn("Retrying request", logging.URL, rawURL, "attempt", attempt+1, "delay", delay, logging.Error, err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryable reports whether a response status code is worth retrying
+func retryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// backoff returns the delay before a retry: the initial backoff doubled for
+// each previous retry, capped at the maximum, with up to 20% jitter
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.config.InitialBackoff
+	for i := 0; i < attempt && delay < c.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if c.config.MaxBackoff > 0 && delay > c.config.MaxBackoff {
+		delay = c.config.MaxBackoff
+	}
+	if delay > 0 {
+		delay += time.Duration(rand.Int64N(int64(delay)/5 + 1))
+	}
+	return delay
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds. HTTP dates are
+// not supported.
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// limiter returns the rate limiter of a host
+func (c *Client) limiter(host string) *hostLimiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	l, ok := c.limiters[host]
+	if !ok {
+		l = &hostLimiter{}
+		if c.config.RequestsPerSecond > 0 {
+			l.interval = time.Duration(float64(time.Second) / c.config.RequestsPerSecond)
+		}
+		c.limiters[host] = l
+	}
+	return l
+}
+
+// hostLimiter spaces requests to a host at least interval apart
+type hostLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// wait blocks until the next request to the host may be sent
+func (l *hostLimiter) wait(ctx context.Context) error {
+	if l.interval == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+// cachePath returns the path of the cached body of a URL
+func (c *Client) cachePath(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(c.config.CacheDir, hex.EncodeToString(sum[:]))
+}
+
+// cachingBody copies a response body to a temporary file as it is read, and
+// moves the file into the cache when the body is closed after being read
+// completely
+type cachingBody struct {
+	io.ReadCloser
+	tmp      *os.File
+	path     string
+	complete bool
+	failed   bool
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && !b.failed {
+		if _, werr := b.tmp.Write(p[:n]); werr != nil {
+			b.failed = true
+		}
+	}
+	if err == io.EOF {
+		b.complete = true
+	}
+	return n, err
+}
+
+func (b *cachingBody) Close() error {
+	err := b.ReadCloser.Close()
+	if cerr := b.tmp.Close(); cerr != nil {
+		b.failed = true
+	}
+	if b.complete && !b.failed {
+		if os.Rename(b.tmp.Name(), b.path) == nil {
+			return err
+		}
+	}
+	os.Remove(b.tmp.Name())
+	return err
+}
@@ -0,0 +1,171 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testConfig() Config {
+	return Config{
+		DialTimeout:           5 * time.Second,
+		ResponseHeaderTimeout: 5 * time.Second,
+		MaxRetries:            2,
+		InitialBackoff:        time.Millisecond,
+		MaxBackoff:            5 * time.Millisecond,
+		UserAgent:             "test-agent",
+	}
+}
+
+func TestGetRetriesTransientFailures(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantErr   bool
+		wantCalls int32
+	}{
+		{name: "success", statuses: []int{200}, wantCalls: 1},
+		{name: "server error then success", statuses: []int{500, 503, 200}, wantCalls: 3},
+		{name: "rate limited then success", statuses: []int{429, 200}, wantCalls: 2},
+		{name: "retries exhausted", statuses: []int{502, 502, 502}, wantErr: true, wantCalls: 3},
+		{name: "not found is not retried", statuses: []int{404}, wantErr: true, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := calls.Add(1)
+				if ua := r.Header.Get("User-Agent"); ua != "test-agent" {
+					t.Errorf("User-Agent = %q, want test-agent", ua)
+				}
+				w.WriteHeader(tt.statuses[n-1])
+				w.Write([]byte("body"))
+			}))
+			defer server.Close()
+
+			body, err := New(testConfig()).Get(context.Background(), server.URL)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Get returned %q, want an error", body)
+				}
+			} else if err != nil || string(body) != "body" {
+				t.Fatalf("Get = %q, %v, want body", body, err)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("server called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := New(testConfig()).Get(context.Background(), server.URL)
+	if !IsNotFound(err) {
+		t.Errorf("IsNotFound(%v) = false, want true", err)
+	}
+}
+
+func TestGetCached(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte("artifact"))
+	}))
+	defer server.Close()
+
+	config := testConfig()
+	config.CacheDir = t.TempDir()
+	client := New(config)
+
+	for i := 0; i < 2; i++ {
+		body, err := client.GetCached(context.Background(), server.URL+"/artifact.yaml")
+		if err != nil || string(body) != "artifact" {
+			t.Fatalf("GetCached = %q, %v, want artifact", body, err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("server called %d times, want 1", got)
+	}
+
+	// Uncached requests always reach the server
+	if _, err := client.Get(context.Background(), server.URL+"/artifact.yaml"); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("server called %d times, want 2", got)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	config := testConfig()
+	config.RequestsPerSecond = 20
+	client := New(config)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := client.Get(context.Background(), server.URL); err != nil {
+			t.Fatalf("Get returned error: %v", err)
+		}
+	}
+	// The first request is sent right away, the others 50ms apart
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("5 requests at 20 per second took %v, want at least 200ms", elapsed)
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	config := testConfig()
+	config.ResponseHeaderTimeout = 50 * time.Millisecond
+	config.MaxRetries = 0
+	client := New(config)
+
+	// A body read slower than the response header timeout isn't cut off
+	slowBody := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("slow "))
+		w.(http.Flusher).Flush()
+		time.Sleep(4 * config.ResponseHeaderTimeout)
+		w.Write([]byte("body"))
+	}))
+	defer slowBody.Close()
+	if body, err := client.Get(context.Background(), slowBody.URL); err != nil || string(body) != "slow body" {
+		t.Errorf("Get of a slow body = %q, %v, want the whole body", body, err)
+	}
+
+	// Slow response headers are cut off
+	slowHeaders := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(4 * config.ResponseHeaderTimeout)
+		w.Write([]byte("body"))
+	}))
+	defer slowHeaders.Close()
+	if body, err := client.Get(context.Background(), slowHeaders.URL); err == nil {
+		t.Errorf("Get of slow headers = %q, want a timeout", body)
+	}
+
+	// Reading the body stops once the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), config.ResponseHeaderTimeout)
+	defer cancel()
+	if body, err := client.Get(ctx, slowBody.URL); err == nil {
+		t.Errorf("Get of a slow body past its context = %q, want an error", body)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != Default {
+		t.Errorf("FromContext of a context without a client = %p, want Default", got)
+	}
+	client := New(testConfig())
+	if got := FromContext(NewContext(context.Background(), client)); got != client {
+		t.Errorf("FromContext = %p, want the context's client %p", got, client)
+	}
+}
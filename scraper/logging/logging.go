@@ -0,0 +1,60 @@
+// Package logging configures the scraper's structured logger and carries
+// loggers with a build's fields through contexts, so that the log lines of a
+// build's scrape, process and store steps can be correlated.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Keys of the fields shared by the log lines of all binaries
+const (
+	TestName = "test_name"
+	BuildID  = "build_id"
+	PR       = "pr"
+	URL      = "url"
+	Duration = "duration"
+	Error    = "error"
+)
+
+type contextKey struct{}
+
+// Setup makes a logger writing to w the default logger. format is text or
+// json, and level is debug, info, warn or error.
+func Setup(w io.Writer, format, level string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("invalid log format %q, must be \"text\" or \"json\"", format)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// With returns a copy of ctx carrying the context's logger with the given
+// fields added
+func With(ctx context.Context, args ...any) context.Context {
+	return context.WithValue(ctx, contextKey{}, FromContext(ctx).With(args...))
+}
+
+// FromContext returns the logger carried by ctx, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
@@ -2,10 +2,15 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"os"
 	"time"
 
 	"github.com/hypershift-community/ci-testgrid/scraper/db"
+	"github.com/hypershift-community/ci-testgrid/scraper/httpclient"
+	"github.com/hypershift-community/ci-testgrid/scraper/logging"
+	"github.com/hypershift-community/ci-testgrid/scraper/metrics"
+	"github.com/hypershift-community/ci-testgrid/scraper/notify"
 	"github.com/hypershift-community/ci-testgrid/scraper/processor"
 	"github.com/hypershift-community/ci-testgrid/scraper/scraper"
 	"github.com/spf13/cobra"
@@ -15,75 +20,97 @@ type Scraper struct {
 	startURL string
 	suffix   string
 	testName string
+	// parser is the name of the processor.Parser of the job's logs
+	parser string
+	// gcswebURL is the base URL of the gcsweb serving the jobs' artifacts
+	gcswebURL string
 }
 
-func NewScraper(startURL, suffix, testName string) *Scraper {
+func NewScraper(startURL, suffix, testName, parser string) *Scraper {
 	return &Scraper{
-		startURL: startURL,
-		suffix:   suffix,
-		testName: testName,
+		startURL:  startURL,
+		suffix:    suffix,
+		testName:  testName,
+		parser:    parser,
+		gcswebURL: scraper.DefaultGCSWebURL,
 	}
 }
 
-func (s *Scraper) Run() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Second)
+// Run scrapes the job's history, newest first, and stores the jobs that are
+// not in repo yet
+func (s *Scraper) Run(ctx context.Context, repo db.Repository) error {
+	ctx, cancel := context.WithTimeout(ctx, 600*time.Second)
 	defer cancel()
+	ctx = logging.With(ctx, logging.TestName, s.testName)
+	logger := logging.FromContext(ctx)
 
-	// Connect to MongoDB.
-	client, err := db.Connect()
+	parser, err := processor.LookupParser(s.parser)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if err := client.Disconnect(ctx); err != nil {
-			log.Printf("Error disconnecting MongoDB: %v", err)
-		}
-	}()
-	collection := client.Database("ci").Collection("jobs")
+
+	if err := repo.EnsureIndexes(ctx); err != nil {
+		logger.Error("Error creating indexes", logging.Error, err)
+	}
 
 	jobCount := 0
 	pageURL := s.startURL
 
 	for jobCount < 100 {
-		log.Printf("Scraping jobs page for %s: %s\n", s.testName, pageURL)
-		jobs, nextPage, err := scraper.ScrapeJobs(pageURL, s.suffix)
+		logger.Info("Scraping jobs page", logging.URL, pageURL)
+		jobs, nextPage, err := scraper.ScrapeJobs(ctx, pageURL, s.gcswebURL, s.suffix)
 		if err != nil {
-			log.Printf("Error scraping page: %v", err)
+			metrics.PagesFetched.WithLabelValues(s.testName, "error").Inc()
+			logger.Error("Error scraping page", logging.URL, pageURL, logging.Error, err)
 			break
 		}
+		metrics.PagesFetched.WithLabelValues(s.testName, "success").Inc()
 		if len(jobs) == 0 {
-			log.Println("No jobs found on page.")
+			logger.Info("No jobs found on page", logging.URL, pageURL)
 			break
 		}
 
 		for _, job := range jobs {
-			// Stop scraping if the job is already in MongoDB.
-			exists, err := db.JobExists(ctx, collection, job.ID)
+			// Correlate the log lines of the build's steps
+			jobCtx := logging.With(ctx, logging.BuildID, job.ID, logging.PR, job.PR)
+			jobLogger := logging.FromContext(jobCtx)
+			start := time.Now()
+
+			// Stop scraping if the job is already stored.
+			exists, err := repo.JobExists(jobCtx, job.ID)
 			if err != nil {
-				log.Printf("Error checking job %s: %v\n", job.ID, err)
+				jobLogger.Error("Error checking job", logging.Error, err)
 				continue
 			}
 			if exists {
-				log.Printf("Job %s already stored. Stopping scraping.\n", job.ID)
+				jobLogger.Info("Job already stored, stopping scraping")
 				return nil
 			}
 
-			// Process the job: fetch log, extract and parse JUnit XML.
-			tests, err := processor.ProcessJob(&job)
+			// Process the job: fetch and parse its log.
+			tests, err := processor.ProcessJob(jobCtx, &job, parser)
 			if err != nil {
-				log.Printf("Error processing job %s: %v", job.ID, err)
+				metrics.BuildsFailed.WithLabelValues(s.testName, "process").Inc()
+				jobLogger.Error("Error processing job", logging.URL, job.LogURL, logging.Error, err)
 				continue
 			}
 			job.Tests = tests
 			job.TestName = s.testName
 
-			// Store the job in MongoDB.
-			err = db.InsertJob(ctx, collection, &job)
+			// Store the job.
+			err = repo.InsertJob(jobCtx, &job)
 			if err != nil {
-				log.Printf("Error storing job %s: %v", job.ID, err)
+				metrics.BuildsFailed.WithLabelValues(s.testName, "store").Inc()
+				jobLogger.Error("Error storing job", logging.Error, err)
 				continue
 			}
-			log.Printf("Stored job %s successfully.\n", job.ID)
+			jobLogger.Info("Stored job", "tests", len(tests), logging.Duration, time.Since(start))
+			metrics.BuildsProcessed.WithLabelValues(s.testName).Inc()
+
+			// Let the reporter update the PR's comment.
+			if err := notify.JobStored(jobCtx, &job); err != nil {
+				jobLogger.Error("Error notifying reporter", logging.Error, err)
+			}
 			jobCount++
 			if jobCount >= 100 {
 				break
@@ -91,27 +118,34 @@ func (s *Scraper) Run() error {
 		}
 
 		if nextPage == "" {
-			log.Println("No more pages to scrape.")
+			logger.Info("No more pages to scrape")
 			break
 		}
 		pageURL = nextPage
 	}
 
-	log.Printf("Scraping complete for %s. %d jobs processed.\n", s.testName, jobCount)
+	logger.Info("Scraping complete", "jobs", jobCount)
 	return nil
 }
 
 func createRootCommand() *cobra.Command {
+	var logFormat, logLevel string
+	httpConfig := httpclient.DefaultConfig()
+
 	cmd := &cobra.Command{
 		Use:   "ci-scraper",
 		Short: "CI TestGrid scraper for OpenShift CI jobs",
 		Long:  `A tool that scrapes test results from OpenShift CI jobs and stores them in MongoDB.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return logging.Setup(os.Stderr, logFormat, logLevel)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Create e2e-aws scraper
 			awsScraper := NewScraper(
 				"https://prow.ci.openshift.org/job-history/gs/test-platform-results/pr-logs/directory/pull-ci-openshift-hypershift-main-e2e-aws",
 				"/artifacts/e2e-aws/hypershift-aws-run-e2e-nested/build-log.txt",
 				"e2e-aws",
+				"go-test",
 			)
 
 			// Create e2e-aks scraper
@@ -119,28 +153,59 @@ func createRootCommand() *cobra.Command {
 				"https://prow.ci.openshift.org/job-history/gs/test-platform-results/pr-logs/directory/pull-ci-openshift-hypershift-main-e2e-aks",
 				"/artifacts/e2e-aks/hypershift-azure-run-e2e/build-log.txt",
 				"e2e-aks",
+				"go-test",
 			)
 
+			// Fetch pages, logs and artifacts with the client configured by
+			// the flags.
+			ctx := httpclient.NewContext(context.Background(), httpclient.New(httpConfig))
+
+			// Connect to MongoDB.
+			client, err := db.Connect()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := client.Disconnect(ctx); err != nil {
+					slog.Error("Error disconnecting MongoDB", logging.Error, err)
+				}
+			}()
+			repo := db.NewMongoRepository(client.Database("ci").Collection("jobs"))
+
 			// Run e2e-aws scraper
-			if err := awsScraper.Run(); err != nil {
-				log.Printf("Error running e2e-aws scraper: %v", err)
+			if err := awsScraper.Run(ctx, repo); err != nil {
+				slog.Error("Error running scraper", logging.TestName, "e2e-aws", logging.Error, err)
 			}
 
 			// Run e2e-aks scraper
-			if err := aksScraper.Run(); err != nil {
-				log.Printf("Error running e2e-aks scraper: %v", err)
+			if err := aksScraper.Run(ctx, repo); err != nil {
+				slog.Error("Error running scraper", logging.TestName, "e2e-aks", logging.Error, err)
+			}
+
+			// Push metrics for this run, if a Pushgateway is configured
+			metrics.LastRunTimestamp.SetToCurrentTime()
+			if err := metrics.Push(); err != nil {
+				slog.Error("Error pushing metrics", logging.Error, err)
 			}
 
 			return nil
 		},
 	}
 
+	cmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "Log format, text or json")
+	cmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "Log level: debug, info, warn or error")
+	cmd.PersistentFlags().DurationVar(&httpConfig.DialTimeout, "http-dial-timeout", httpConfig.DialTimeout, "Timeout of connecting to a host for each HTTP request attempt")
+	cmd.PersistentFlags().DurationVar(&httpConfig.ResponseHeaderTimeout, "http-response-header-timeout", httpConfig.ResponseHeaderTimeout, "Timeout of waiting for the response headers of each HTTP request attempt; reading the body is only limited by the scrape timeout")
+	cmd.PersistentFlags().IntVar(&httpConfig.MaxRetries, "http-retries", httpConfig.MaxRetries, "How many times HTTP requests are retried on network errors, 5xx and 429 responses")
+	cmd.PersistentFlags().Float64Var(&httpConfig.RequestsPerSecond, "http-rate-limit", httpConfig.RequestsPerSecond, "Maximum HTTP requests per second to each host (0 disables)")
+	cmd.PersistentFlags().StringVar(&httpConfig.CacheDir, "http-cache-dir", "", "Directory to cache build logs and artifacts in (disabled if empty)")
+
 	return cmd
 }
 
 func main() {
 	rootCmd := createRootCommand()
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+		os.Exit(1)
 	}
 }
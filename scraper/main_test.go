@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hypershift-community/ci-testgrid/scraper/db"
+	"github.com/hypershift-community/ci-testgrid/scraper/fixtures"
+	"github.com/hypershift-community/ci-testgrid/scraper/httpclient"
+	"github.com/hypershift-community/ci-testgrid/scraper/types"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// e2eJobsGolden holds the jobs scraped from the recordings in testdata/e2e.
+// The UI's and the reporter's end-to-end tests render it, so it is the
+// contract between the scraper and the other components.
+var e2eJobsGolden = filepath.Join("testdata", "e2e", "jobs.golden.json")
+
+// TestScrapeEndToEnd scrapes the recorded job history of e2e-aws, from its
+// pages to the build logs and artifacts of each build, into an in-memory
+// repository
+func TestScrapeEndToEnd(t *testing.T) {
+	server := httptest.NewServer(fixtures.Handler(filepath.Join("testdata", "e2e")))
+	defer server.Close()
+	serverURL := server.URL
+	// Fetch without retries or a cache
+	ctx := httpclient.NewContext(context.Background(), httpclient.New(httpclient.Config{}))
+	t.Setenv("REPORTER_URL", "")
+	t.Setenv("SKIP_ARTIFACTS", "")
+
+	scraper := NewScraper(
+		serverURL+"/job-history/gs/test-platform-results/pr-logs/directory/pull-ci-openshift-hypershift-main-e2e-aws",
+		"/artifacts/e2e-aws/hypershift-aws-run-e2e-nested/build-log.txt",
+		"e2e-aws",
+		"go-test",
+	)
+	scraper.gcswebURL = serverURL
+	repo := db.NewMemoryRepository()
+	if err := scraper.Run(ctx, repo); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	jobs := repo.Jobs()
+	// Make the jobs independent of the server's address
+	for i := range jobs {
+		jobs[i].LogURL = "https://gcsweb.example" + jobs[i].LogURL[len(serverURL):]
+		jobs[i].ArtifactsURL = "https://gcsweb.example" + jobs[i].ArtifactsURL[len(serverURL):]
+	}
+	got, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	if *update {
+		if err := os.WriteFile(e2eJobsGolden, got, 0o644); err != nil {
+			t.Fatalf("writing golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(e2eJobsGolden)
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("stored jobs differ from %s, run go test -update to update it:\n%s", e2eJobsGolden, got)
+	}
+
+	// A second run stops at the first job already stored
+	if err := scraper.Run(ctx, repo); err != nil {
+		t.Fatalf("second Run returned error: %v", err)
+	}
+	if n := len(repo.Jobs()); n != len(jobs) {
+		t.Errorf("second run stored %d jobs, want %d", n-len(jobs), 0)
+	}
+
+	checkStoredJobs(t, jobs)
+}
+
+// checkStoredJobs spells out what the golden file is expected to hold, so
+// that updating it can't silently accept a regression
+func checkStoredJobs(t *testing.T, jobs []types.Job) {
+	t.Helper()
+	// The pending build on the second page is skipped
+	if len(jobs) != 4 {
+		t.Fatalf("stored %d jobs, want 4", len(jobs))
+	}
+	byID := make(map[string]types.Job)
+	for _, job := range jobs {
+		byID[job.ID] = job
+	}
+
+	failed := byID["1979400000000000004"]
+	if failed.PR != 5021 || failed.SHA == "" || failed.TestName != "e2e-aws" || len(failed.Tests) != 3 || len(failed.Steps) != 4 {
+		t.Errorf("job 1979400000000000004 = PR %d, SHA %q, test name %q, %d tests and %d steps, want PR 5021 with 3 tests and 4 steps", failed.PR, failed.SHA, failed.TestName, len(failed.Tests), len(failed.Steps))
+	}
+	for _, test := range failed.Tests {
+		if test.Name == "TestCreateCluster" && (test.Signature == "" || test.HostedCluster == "" || len(test.NodePools) != 1) {
+			t.Errorf("TestCreateCluster = %+v, want a signature, its HostedCluster and NodePool", test)
+		}
+	}
+
+	install := byID["1979400000000000003"]
+	if install.FailureStage != types.FailureStageInstall || len(install.Tests) != 0 {
+		t.Errorf("job 1979400000000000003 failed in stage %q with %d tests, want the install stage without tests", install.FailureStage, len(install.Tests))
+	}
+	if passed := byID["1979400000000000002"]; passed.Result != "SUCCESS" || passed.FailureStage != "" {
+		t.Errorf("job 1979400000000000002 = %s in stage %q, want SUCCESS", passed.Result, passed.FailureStage)
+	}
+}
@@ -0,0 +1,67 @@
+// Package metrics defines the scraper's Prometheus metrics. The scraper runs as
+// a CronJob, so metrics are pushed to a Pushgateway when a run completes
+// instead of being scraped.
+package metrics
+
+import (
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// Registry holds the scraper's metrics. A dedicated registry keeps the Go
+// runtime metrics of a short-lived process out of the Pushgateway.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// PagesFetched counts job history pages fetched, by test name and
+	// whether the fetch succeeded.
+	PagesFetched = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "scraper_pages_fetched_total",
+		Help: "Job history pages fetched, by test name and result.",
+	}, []string{"test_name", "result"})
+
+	// BuildsProcessed counts builds processed and stored, by test name.
+	BuildsProcessed = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "scraper_builds_processed_total",
+		Help: "Builds processed and stored, by test name.",
+	}, []string{"test_name"})
+
+	// BuildsFailed counts builds that couldn't be processed or stored, by
+	// test name and the stage that failed.
+	BuildsFailed = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "scraper_builds_failed_total",
+		Help: "Builds that couldn't be processed or stored, by test name and stage.",
+	}, []string{"test_name", "stage"})
+
+	// ArtifactFetchDuration observes how long fetching a test's artifacts
+	// takes.
+	ArtifactFetchDuration = promauto.With(Registry).NewHistogram(prometheus.HistogramOpts{
+		Name:    "scraper_artifact_fetch_duration_seconds",
+		Help:    "Time taken to fetch a test's HostedCluster and NodePool artifacts.",
+		Buckets: prometheus.ExponentialBuckets(0.1, 2, 10),
+	})
+
+	// ArtifactFetchErrors counts failed artifact fetches.
+	ArtifactFetchErrors = promauto.With(Registry).NewCounter(prometheus.CounterOpts{
+		Name: "scraper_artifact_fetch_errors_total",
+		Help: "Failed fetches of a test's artifacts.",
+	})
+
+	// LastRunTimestamp records when the scraper last completed a run.
+	LastRunTimestamp = promauto.With(Registry).NewGauge(prometheus.GaugeOpts{
+		Name: "scraper_last_run_timestamp_seconds",
+		Help: "Unix time the scraper last completed a run.",
+	})
+)
+
+// Push pushes the metrics to the Pushgateway at PUSHGATEWAY_URL, if set.
+func Push() error {
+	url := os.Getenv("PUSHGATEWAY_URL")
+	if url == "" {
+		return nil
+	}
+	return push.New(url, "ci-scraper").Gatherer(Registry).Push()
+}
@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hypershift-community/ci-testgrid/scraper/types"
+)
+
+// jobNotification is the body expected by the reporter's internal endpoint.
+type jobNotification struct {
+	JobID    string `json:"job_id"`
+	TestName string `json:"test_name"`
+	PR       int    `json:"pr"`
+}
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// JobStored tells the reporter that a job has been stored so it can update
+// the PR's comment right away. It does nothing unless REPORTER_URL is set.
+func JobStored(ctx context.Context, job *types.Job) error {
+	reporterURL := os.Getenv("REPORTER_URL")
+	if reporterURL == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(jobNotification{
+		JobID:    job.ID,
+		TestName: job.TestName,
+		PR:       job.PR,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(reporterURL, "/")+"/internal/jobs", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token := os.Getenv("REPORTER_INTERNAL_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notifying reporter: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("reporter returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -1,25 +1,30 @@
 package processor
 
 import (
+	"context"
 	"fmt"
-	"io"
-	"log"
-	"net/http"
 	"strings"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/hypershift-community/ci-testgrid/scraper/httpclient"
+	"github.com/hypershift-community/ci-testgrid/scraper/logging"
+	"github.com/hypershift-community/ci-testgrid/scraper/metrics"
 	"github.com/hypershift-community/ci-testgrid/scraper/types"
 )
 
 // fetchArtifacts populates HostedCluster and NodePools fields on each test
 // by fetching YAML artifacts from GCS. Errors are logged and skipped.
-func fetchArtifacts(logURL string, tests []types.Test) {
+func fetchArtifacts(ctx context.Context, logURL string, tests []types.Test) {
 	artifactBaseURL := strings.TrimSuffix(logURL, "build-log.txt") + "artifacts/"
 
 	for i := range tests {
-		hc, nps, err := fetchTestArtifacts(artifactBaseURL, tests[i].Name)
+		start := time.Now()
+		hc, nps, err := fetchTestArtifacts(ctx, artifactBaseURL, tests[i].Name)
+		metrics.ArtifactFetchDuration.Observe(time.Since(start).Seconds())
 		if err != nil {
-			log.Printf("Error fetching artifacts for test %s: %v", tests[i].Name, err)
+			metrics.ArtifactFetchErrors.Inc()
+			logging.FromContext(ctx).Warn("Error fetching artifacts", "test", tests[i].Name, logging.Error, err)
 			continue
 		}
 		tests[i].HostedCluster = hc
@@ -28,9 +33,9 @@ func fetchArtifacts(logURL string, tests []types.Test) {
 }
 
 // fetchTestArtifacts fetches the HostedCluster and NodePool YAMLs for a single test.
-func fetchTestArtifacts(artifactBaseURL, testName string) (string, []string, error) {
+func fetchTestArtifacts(ctx context.Context, artifactBaseURL, testName string) (string, []string, error) {
 	namespacesURL := artifactBaseURL + testName + "/namespaces/"
-	namespaces, err := listGCSDirectory(namespacesURL)
+	namespaces, err := listGCSDirectory(ctx, namespacesURL)
 	if err != nil {
 		return "", nil, fmt.Errorf("listing namespaces: %w", err)
 	}
@@ -45,16 +50,16 @@ func fetchTestArtifacts(artifactBaseURL, testName string) (string, []string, err
 		}
 
 		hcDir := namespacesURL + ns + "hypershift.openshift.io/hostedclusters/"
-		hcFiles, err := listGCSDirectory(hcDir)
+		hcFiles, err := listGCSDirectory(ctx, hcDir)
 		if err == nil {
 			for _, f := range hcFiles {
 				if !strings.HasSuffix(f, ".yaml") {
 					continue
 				}
 				if hostedCluster == "" {
-					content, err := fetchFileContent(hcDir + f)
+					content, err := fetchFileContent(ctx, hcDir+f)
 					if err != nil {
-						log.Printf("Error fetching hostedcluster file %s: %v", f, err)
+						logging.FromContext(ctx).Warn("Error fetching hostedcluster file", logging.URL, hcDir+f, logging.Error, err)
 						continue
 					}
 					hostedCluster = content
@@ -63,15 +68,15 @@ func fetchTestArtifacts(artifactBaseURL, testName string) (string, []string, err
 		}
 
 		npDir := namespacesURL + ns + "hypershift.openshift.io/nodepools/"
-		npFiles, err := listGCSDirectory(npDir)
+		npFiles, err := listGCSDirectory(ctx, npDir)
 		if err == nil {
 			for _, f := range npFiles {
 				if !strings.HasSuffix(f, ".yaml") {
 					continue
 				}
-				content, err := fetchFileContent(npDir + f)
+				content, err := fetchFileContent(ctx, npDir+f)
 				if err != nil {
-					log.Printf("Error fetching nodepool file %s: %v", f, err)
+					logging.FromContext(ctx).Warn("Error fetching nodepool file", logging.URL, npDir+f, logging.Error, err)
 					continue
 				}
 				nodePools = append(nodePools, content)
@@ -82,19 +87,16 @@ func fetchTestArtifacts(artifactBaseURL, testName string) (string, []string, err
 	return hostedCluster, nodePools, nil
 }
 
-// listGCSDirectory fetches a gcsweb HTML directory listing and returns entry names.
-func listGCSDirectory(url string) ([]string, error) {
-	resp, err := http.Get(url)
+// listGCSDirectory fetches a gcsweb HTML directory listing and returns entry
+// names. The artifacts of finished builds don't change, so listings are cached.
+func listGCSDirectory(ctx context.Context, url string) ([]string, error) {
+	body, err := httpclient.FromContext(ctx).OpenCached(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("fetching directory listing: %w", err)
 	}
-	defer resp.Body.Close()
+	defer body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("directory listing returned status %d", resp.StatusCode)
-	}
-
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	doc, err := goquery.NewDocumentFromReader(body)
 	if err != nil {
 		return nil, fmt.Errorf("parsing directory listing HTML: %w", err)
 	}
@@ -121,21 +123,11 @@ func listGCSDirectory(url string) ([]string, error) {
 }
 
 // fetchFileContent fetches a raw file from gcsweb and returns its content as a string.
-func fetchFileContent(url string) (string, error) {
-	resp, err := http.Get(url)
+func fetchFileContent(ctx context.Context, url string) (string, error) {
+	body, err := httpclient.FromContext(ctx).GetCached(ctx, url)
 	if err != nil {
 		return "", fmt.Errorf("fetching file: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("file fetch returned status %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("reading file body: %w", err)
-	}
 
 	return string(body), nil
 }
@@ -3,6 +3,7 @@
 package processor
 
 import (
+	"context"
 	"os"
 	"strings"
 	"testing"
@@ -30,7 +31,7 @@ func TestFetchTestArtifacts(t *testing.T) {
 	artifactBaseURL := testArtifactBaseURL(t)
 	testName := "TestCreateCluster"
 
-	hostedCluster, nodePools, err := fetchTestArtifacts(artifactBaseURL, testName)
+	hostedCluster, nodePools, err := fetchTestArtifacts(context.Background(), artifactBaseURL, testName)
 	if err != nil {
 		t.Fatalf("fetchTestArtifacts returned error: %v", err)
 	}
@@ -59,7 +60,7 @@ func TestListGCSDirectory(t *testing.T) {
 	artifactBaseURL := testArtifactBaseURL(t)
 	url := artifactBaseURL + "TestCreateCluster/namespaces/"
 
-	entries, err := listGCSDirectory(url)
+	entries, err := listGCSDirectory(context.Background(), url)
 	if err != nil {
 		t.Fatalf("listGCSDirectory returned error: %v", err)
 	}
@@ -0,0 +1,22 @@
+package processor
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hypershift-community/ci-testgrid/scraper/fixtures"
+	"github.com/hypershift-community/ci-testgrid/scraper/httpclient"
+)
+
+// serveGCSWeb serves a directory like gcsweb does. It returns the URL of the
+// directory, and a context carrying a client without retries or a cache to
+// fetch it with.
+func serveGCSWeb(t *testing.T, dir string) (context.Context, string) {
+	t.Helper()
+	server := httptest.NewServer(fixtures.GCSWeb(dir))
+	t.Cleanup(server.Close)
+
+	ctx := httpclient.NewContext(context.Background(), httpclient.New(httpclient.Config{}))
+	return ctx, server.URL + "/gcs/"
+}
@@ -0,0 +1,169 @@
+package processor
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hypershift-community/ci-testgrid/scraper/types"
+)
+
+// Regex patterns for Ginkgo v2's console output
+var (
+	// ginkgoSeparatorRe matches the lines between the reports of specs
+	ginkgoSeparatorRe = regexp.MustCompile(`^-{30}$`)
+	// ginkgoStatusRe matches the line reporting a spec's state and runtime,
+	// e.g. "• [FAILED] [12.345 seconds]", "S [SKIPPED] [0.000 seconds]",
+	// "P [PENDING]" or "• [0.010 seconds]" for a passed spec
+	ginkgoStatusRe = regexp.MustCompile(`^([•SP]) (?:\[([A-Z][A-Z0-9 -]*)\] ?)?(?:\[([0-9.]+) seconds\])?$`)
+	// ginkgoLocationRe matches the code location printed after a spec's name
+	ginkgoLocationRe = regexp.MustCompile(`^\S+:\d+$`)
+	// ginkgoNodeRe matches the marker of the node a spec ran or failed in,
+	// which Ginkgo inserts in the spec's name
+	ginkgoNodeRe = regexp.MustCompile(`\[(It|BeforeEach|JustBeforeEach|AfterEach|JustAfterEach|BeforeAll|AfterAll)\] `)
+	ansiEscapeRe = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+)
+
+// ginkgoSpec accumulates the report of a spec, which Ginkgo prints between
+// separator lines
+type ginkgoSpec struct {
+	test   *types.Test
+	caps   map[*types.Test]*logCap
+	header string // The first line, the spec's name in verbose output
+	name   string // The name printed after the status line
+	status bool
+	// expectName and expectLocation are set while the lines naming the spec
+	// are expected
+	expectName     bool
+	expectLocation bool
+}
+
+func newGinkgoSpec() *ginkgoSpec {
+	return &ginkgoSpec{test: &types.Test{}, caps: make(map[*types.Test]*logCap)}
+}
+
+// parseGinkgo returns the specs reported in Ginkgo v2's console output. Passed
+// specs are only reported in verbose output, i.e. with ginkgo -v. Ginkgo
+// runs that write a JUnit report are better parsed with the junit parser.
+func parseGinkgo(in io.Reader) ([]types.Test, error) {
+	reader, err := newLogReader(in)
+	if err != nil {
+		return nil, err
+	}
+
+	// Specs are keyed by name, so the last attempt of a retried spec wins
+	var tests []types.Test
+	index := make(map[string]int)
+	add := func(test types.Test) {
+		if i, ok := index[test.Name]; ok {
+			tests[i] = test
+			return
+		}
+		index[test.Name] = len(tests)
+		tests = append(tests, test)
+	}
+
+	spec := newGinkgoSpec()
+	for {
+		line, err := reader.readLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading build log: %w", err)
+		}
+		line = ansiEscapeRe.ReplaceAllString(line, "")
+
+		if ginkgoSeparatorRe.MatchString(line) {
+			if test, ok := spec.result(); ok {
+				add(test)
+			}
+			spec = newGinkgoSpec()
+			continue
+		}
+		spec.handle(line)
+	}
+	if test, ok := spec.result(); ok {
+		add(test)
+	}
+	return tests, nil
+}
+
+// handle processes a line of a spec's report
+func (s *ginkgoSpec) handle(line string) {
+	if matches := ginkgoStatusRe.FindStringSubmatch(line); matches != nil && !s.status {
+		s.status = true
+		s.test.Result = ginkgoResult(matches[1], matches[2])
+		if seconds, err := strconv.ParseFloat(matches[3], 64); err == nil {
+			s.test.Duration = time.Duration(seconds * float64(time.Second))
+		}
+		s.expectName = true
+		s.expectLocation = false
+		return
+	}
+
+	unindented := line != "" && !strings.HasPrefix(line, " ")
+	switch {
+	case s.expectName:
+		s.expectName = false
+		if unindented {
+			s.name = line
+			s.expectLocation = true
+			return
+		}
+	case s.expectLocation:
+		s.expectLocation = false
+		if ginkgoLocationRe.MatchString(line) {
+			return
+		}
+	case s.header == "" && !s.status && len(s.test.Logs) == 0 && unindented:
+		s.header = line
+		s.expectLocation = true
+		return
+	}
+	appendLogLine(s.test, s.caps, line)
+}
+
+// result returns the spec's test, or false if the lines weren't a spec's
+// report
+func (s *ginkgoSpec) result() (types.Test, bool) {
+	name := s.name
+	if name == "" {
+		name = s.header
+	}
+	if !s.status || name == "" {
+		return types.Test{}, false
+	}
+	test := *s.test
+	test.Name = ginkgoNodeRe.ReplaceAllString(name, "")
+	if test.Result == "pass" {
+		test.Logs = nil
+		return test, true
+	}
+
+	markTruncated(s.caps)
+	test.Logs = s.test.Logs
+	for len(test.Logs) > 0 && strings.TrimSpace(test.Logs[0]) == "" {
+		test.Logs = test.Logs[1:]
+	}
+	for len(test.Logs) > 0 && strings.TrimSpace(test.Logs[len(test.Logs)-1]) == "" {
+		test.Logs = test.Logs[:len(test.Logs)-1]
+	}
+	return test, true
+}
+
+// ginkgoResult maps a spec's status line to a result
+func ginkgoResult(marker, state string) string {
+	switch {
+	case marker == "S" || marker == "P" || state == "SKIPPED" || state == "PENDING":
+		return "skip"
+	case state == "" || strings.HasPrefix(state, "FLAKEY"):
+		return "pass"
+	default:
+		// FAILED, PANICKED, TIMEDOUT, INTERRUPTED or ABORTED
+		return "fail"
+	}
+}
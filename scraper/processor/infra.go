@@ -0,0 +1,175 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/hypershift-community/ci-testgrid/scraper/httpclient"
+	"github.com/hypershift-community/ci-testgrid/scraper/logging"
+	"github.com/hypershift-community/ci-testgrid/scraper/types"
+)
+
+// maxFailureReasonLen caps the stored reason of a job's failure
+const maxFailureReasonLen = 300
+
+// infraRules classify the failures ci-operator reports, in the order of the
+// stages, so the earliest failed stage wins. They only match failures, not
+// the lines ci-operator logs on every run, such as "Acquiring leases".
+var infraRules = []struct {
+	stage string
+	re    *regexp.Regexp
+}{
+	{types.FailureStageLease, regexp.MustCompile(`(?i)(failed to|could not) acquire leases?|leases? .*(timed out|timeout)`)},
+	{types.FailureStageImages, regexp.MustCompile(`(?i)ErrImagePull|ImagePullBackOff|(failed to|could not|unable to) (pull|import|build|tag|resolve inputs)|^(Build image|Import the release payload|Find all of the input images)`)},
+	{types.FailureStageInstall, regexp.MustCompile(`(?i)Run multi-stage test pre phase|"?pre"? (phase|steps?) failed|\b(ipi|upi|hypershift)-install\S* (failed|container test)|install(ation)? failed|failed to install`)},
+	{types.FailureStageTest, regexp.MustCompile(`(?i)Run multi-stage test test phase|"?test"? (phase|steps?) failed|container test`)},
+	{types.FailureStagePost, regexp.MustCompile(`(?i)Run multi-stage test post phase|"?post"? (phase|steps?) failed`)},
+}
+
+// infraClassifier keeps the earliest failed stage among the lines it is given
+type infraClassifier struct {
+	rule   int // Index of the matched rule, len(infraRules) if none matched
+	reason string
+}
+
+func newInfraClassifier() *infraClassifier {
+	return &infraClassifier{rule: len(infraRules)}
+}
+
+// add classifies a line, keeping it as the reason if its stage is earlier
+// than the stages seen so far
+func (c *infraClassifier) add(line string) {
+	for i := 0; i < c.rule; i++ {
+		if infraRules[i].re.MatchString(line) {
+			c.rule = i
+			c.reason = strings.TrimSpace(line)
+			return
+		}
+	}
+}
+
+// result returns the earliest failed stage and its reason, or false if no
+// line matched
+func (c *infraClassifier) result() (string, string, bool) {
+	if c.rule == len(infraRules) {
+		return "", "", false
+	}
+	reason := c.reason
+	if runes := []rune(reason); len(runes) > maxFailureReasonLen {
+		reason = string(runes[:maxFailureReasonLen])
+	}
+	return infraRules[c.rule].stage, reason, true
+}
+
+// jobArtifactsRoot returns the URL of the directory of a job's artifacts,
+// which holds the ci-operator log and the test steps' artifacts
+func jobArtifactsRoot(logURL string) (string, bool) {
+	i := strings.Index(logURL, "/artifacts/")
+	if i < 0 {
+		return "", false
+	}
+	return logURL[:i+1], true
+}
+
+// classifyFailure sets the failure stage and reason of a failed job none of
+// whose tests failed, so that failures outside of the tests, such as a
+// failed cluster install, don't show as a job without results. The stage is
+// taken from the steps ci-operator reports as failed in
+// artifacts/junit_operator.xml, or else from the failures in its log.
+func classifyFailure(ctx context.Context, job *types.Job, tests []types.Test, logFound bool) {
+	if job.Result != "FAILURE" {
+		return
+	}
+	for _, test := range tests {
+		if test.Result == "fail" {
+			return
+		}
+	}
+	logger := logging.FromContext(ctx)
+
+	c := newInfraClassifier()
+	if root, ok := jobArtifactsRoot(job.LogURL); ok {
+		if err := classifyOperatorJUnit(ctx, root+"artifacts/junit_operator.xml", c); err != nil {
+			logger.Warn("Error reading ci-operator JUnit report", logging.Error, err)
+		}
+		if _, _, ok := c.result(); !ok {
+			if err := classifyOperatorLog(ctx, root+"build-log.txt", c); err != nil {
+				logger.Warn("Error reading ci-operator log", logging.Error, err)
+			}
+		}
+	}
+
+	stage, reason, ok := c.result()
+	switch {
+	case ok:
+	case !logFound:
+		stage, reason = types.FailureStageUnknown, "The test step's build log was not found"
+	default:
+		stage, reason = types.FailureStageUnknown, "The job failed without failed tests"
+	}
+	job.FailureStage, job.FailureReason = stage, reason
+	logger.Info("Classified job failure", "stage", stage, "reason", reason)
+}
+
+// classifyOperatorJUnit classifies the steps ci-operator reports as failed.
+// Each failed step is described by its name and the first line of its
+// failure.
+func classifyOperatorJUnit(ctx context.Context, url string, c *infraClassifier) error {
+	body, err := httpclient.FromContext(ctx).OpenCached(ctx, url)
+	if err != nil {
+		if httpclient.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	defer body.Close()
+
+	steps, err := parseJUnit(body)
+	if err != nil {
+		return err
+	}
+	for _, step := range steps {
+		if step.Result != "fail" {
+			continue
+		}
+		line := step.Name
+		for _, log := range step.Logs {
+			if strings.TrimSpace(log) != "" {
+				line += ": " + strings.TrimSpace(log)
+				break
+			}
+		}
+		c.add(line)
+	}
+	return nil
+}
+
+// classifyOperatorLog classifies the lines of ci-operator's log
+func classifyOperatorLog(ctx context.Context, url string, c *infraClassifier) error {
+	body, err := httpclient.FromContext(ctx).OpenCached(ctx, url)
+	if err != nil {
+		if httpclient.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	defer body.Close()
+
+	reader, err := newLogReader(body)
+	if err != nil {
+		return err
+	}
+	for {
+		line, err := reader.readLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading ci-operator log: %w", err)
+		}
+		c.add(line)
+	}
+}
@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hypershift-community/ci-testgrid/scraper/types"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	// Each directory holds the artifacts of a job
+	ctx, baseURL := serveGCSWeb(t, filepath.Join("testdata", "infra"))
+	logURL := func(job string) string {
+		return baseURL + job + "/artifacts/e2e-aws/hypershift-aws-run-e2e-nested/build-log.txt"
+	}
+
+	tests := []struct {
+		name       string
+		job        types.Job
+		wantStage  string
+		wantReason string
+	}{
+		{
+			name:       "failed install step",
+			job:        types.Job{Result: "FAILURE", LogURL: logURL("install")},
+			wantStage:  types.FailureStageInstall,
+			wantReason: `Run multi-stage test e2e-aws - e2e-aws-ipi-install-install container test: "e2e-aws-ipi-install-install" pod failed after 39m54s`,
+		},
+		{
+			name:       "lease timeout in the ci-operator log",
+			job:        types.Job{Result: "FAILURE", LogURL: logURL("lease")},
+			wantStage:  types.FailureStageLease,
+			wantReason: "* could not run steps: step e2e-aws failed: failed to acquire lease for hypershift-quota-slice",
+		},
+		{
+			name:       "no artifacts",
+			job:        types.Job{Result: "FAILURE", LogURL: logURL("missing")},
+			wantStage:  types.FailureStageUnknown,
+			wantReason: "The test step's build log was not found",
+		},
+		{
+			name: "successful job",
+			job:  types.Job{Result: "SUCCESS", LogURL: logURL("install")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The test step's build log is missing, as the job failed before it
+			parser, _ := LookupParser(DefaultParser)
+			job := tt.job
+			if _, err := ProcessJob(ctx, &job, parser); err != nil && tt.job.Result == "FAILURE" {
+				t.Fatalf("ProcessJob returned error: %v", err)
+			}
+			if job.FailureStage != tt.wantStage || !strings.HasPrefix(job.FailureReason, tt.wantReason) {
+				t.Errorf("failure = %q, %q, want %q, %q...", job.FailureStage, job.FailureReason, tt.wantStage, tt.wantReason)
+			}
+		})
+	}
+
+	t.Run("failed tests", func(t *testing.T) {
+		job := types.Job{Result: "FAILURE", LogURL: logURL("install")}
+		classifyFailure(ctx, &job, []types.Test{{Name: "TestCreateCluster", Result: "fail"}}, true)
+		if job.FailureStage != "" {
+			t.Errorf("failure stage = %q, want none for a job with failed tests", job.FailureStage)
+		}
+	})
+
+	t.Run("passed tests", func(t *testing.T) {
+		job := types.Job{Result: "FAILURE", LogURL: logURL("missing")}
+		classifyFailure(ctx, &job, []types.Test{{Name: "TestCreateCluster", Result: "pass"}}, true)
+		if job.FailureStage != types.FailureStageUnknown {
+			t.Errorf("failure stage = %q, want %q", job.FailureStage, types.FailureStageUnknown)
+		}
+	})
+}
+
+func TestInfraClassifierPrefersEarlierStages(t *testing.T) {
+	c := newInfraClassifier()
+	for _, line := range []string{
+		`"e2e-aws" post steps failed: "e2e-aws" pod "e2e-aws-gather-audit-logs" failed`,
+		`"e2e-aws" test steps failed: "e2e-aws" pod "e2e-aws-hypershift-aws-run-e2e-nested" failed: ErrImagePull`,
+		`"e2e-aws" pre steps failed: "e2e-aws" pod "e2e-aws-hypershift-install" failed`,
+	} {
+		c.add(line)
+	}
+	stage, reason, ok := c.result()
+	if !ok || stage != types.FailureStageImages || !strings.Contains(reason, "ErrImagePull") {
+		t.Errorf("result = %q, %q, %v, want the image pull failure", stage, reason, ok)
+	}
+
+	c = newInfraClassifier()
+	c.add("INFO[2026-10-18T09:15:41Z] Acquiring leases for test e2e-aws: [hypershift-quota-slice]")
+	if _, _, ok := c.result(); ok {
+		t.Error("classified the lease acquisition every job logs as a failure")
+	}
+}
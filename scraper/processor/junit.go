@@ -0,0 +1,148 @@
+package processor
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hypershift-community/ci-testgrid/scraper/types"
+)
+
+// junitTestCase is a <testcase> element of a JUnit XML report
+type junitTestCase struct {
+	Name      string         `xml:"name,attr"`
+	Classname string         `xml:"classname,attr"`
+	Time      string         `xml:"time,attr"`
+	Status    string         `xml:"status,attr"`
+	Failures  []junitMessage `xml:"failure"`
+	Errors    []junitMessage `xml:"error"`
+	Skipped   *junitMessage  `xml:"skipped"`
+	SystemOut string         `xml:"system-out"`
+	SystemErr string         `xml:"system-err"`
+}
+
+// junitMessage is a <failure>, <error> or <skipped> element
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// parseJUnit returns the test cases of a JUnit XML report, as written by
+// Ginkgo, pytest, gotestsum and most other test runners. Cases are named
+// after their class when it differs from their suite's name, e.g.
+// "tests.test_upgrade.test_rollback" for pytest, and Ginkgo's "[It] " marker
+// is dropped so the names match the ginkgo parser's.
+func parseJUnit(in io.Reader) ([]types.Test, error) {
+	r, err := decompress(in)
+	if err != nil {
+		return nil, err
+	}
+	decoder := xml.NewDecoder(r)
+
+	// Cases are keyed by name, so the last attempt of a retried case wins
+	var tests []types.Test
+	index := make(map[string]int)
+	var suites []string // Names of the enclosing <testsuite> elements
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading JUnit report: %w", err)
+		}
+
+		switch el := token.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "testsuite":
+				suites = append(suites, xmlAttr(el, "name"))
+			case "testcase":
+				var tc junitTestCase
+				if err := decoder.DecodeElement(&tc, &el); err != nil {
+					return nil, fmt.Errorf("reading JUnit report: %w", err)
+				}
+				suite := ""
+				if len(suites) > 0 {
+					suite = suites[len(suites)-1]
+				}
+				test := tc.test(suite)
+				if i, ok := index[test.Name]; ok {
+					tests[i] = test
+				} else {
+					index[test.Name] = len(tests)
+					tests = append(tests, test)
+				}
+			}
+		case xml.EndElement:
+			if el.Name.Local == "testsuite" && len(suites) > 0 {
+				suites = suites[:len(suites)-1]
+			}
+		}
+	}
+	return tests, nil
+}
+
+// test converts a test case of a suite to a test. Failed and skipped tests
+// keep their messages and output as logs.
+func (tc junitTestCase) test(suite string) types.Test {
+	name := strings.TrimPrefix(tc.Name, "[It] ")
+	if tc.Classname != "" && tc.Classname != suite {
+		name = tc.Classname + "." + name
+	}
+	test := types.Test{Name: name, Result: "pass"}
+	if seconds, err := strconv.ParseFloat(tc.Time, 64); err == nil {
+		test.Duration = time.Duration(seconds * float64(time.Second))
+	}
+
+	var messages []junitMessage
+	switch {
+	case len(tc.Failures) > 0 || len(tc.Errors) > 0:
+		test.Result = "fail"
+		messages = append(tc.Failures, tc.Errors...)
+	case tc.Skipped != nil || tc.Status == "skipped" || tc.Status == "pending":
+		test.Result = "skip"
+		if tc.Skipped != nil {
+			messages = []junitMessage{*tc.Skipped}
+		}
+	default:
+		return test
+	}
+
+	caps := make(map[*types.Test]*logCap)
+	appendLines := func(text string) {
+		text = strings.TrimRight(text, "\n")
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+		for _, line := range strings.Split(text, "\n") {
+			appendLogLine(&test, caps, strings.TrimSuffix(line, "\r"))
+		}
+	}
+	for _, m := range messages {
+		// The text usually repeats the message, so it is only logged when
+		// there is no text
+		if strings.TrimSpace(m.Text) != "" {
+			appendLines(m.Text)
+		} else {
+			appendLines(m.Message)
+		}
+	}
+	appendLines(tc.SystemOut)
+	appendLines(tc.SystemErr)
+	markTruncated(caps)
+	return test
+}
+
+// xmlAttr returns the value of an element's attribute, or "" if it's missing
+func xmlAttr(el xml.StartElement, name string) string {
+	for _, attr := range el.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
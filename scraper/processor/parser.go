@@ -0,0 +1,81 @@
+package processor
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hypershift-community/ci-testgrid/scraper/types"
+)
+
+// DefaultParser is the parser of jobs that don't select one
+const DefaultParser = "go-test"
+
+// Parser extracts test results from a job's log. Parsers are registered by
+// name, and each job definition selects one, so supporting a new test
+// framework only takes a new Parser.
+type Parser interface {
+	// Parse reads a log, which may be gzip-compressed, and returns its tests.
+	// Only failed and skipped tests need logs.
+	Parse(in io.Reader) ([]types.Test, error)
+}
+
+// ParserFunc adapts a function to the Parser interface
+type ParserFunc func(in io.Reader) ([]types.Test, error)
+
+func (f ParserFunc) Parse(in io.Reader) ([]types.Test, error) {
+	return f(in)
+}
+
+var (
+	parsersMu sync.RWMutex
+	parsers   = make(map[string]Parser)
+)
+
+func init() {
+	// go test -v text output, or go test -json event streams if the log
+	// contains any
+	Register("go-test", ParserFunc(parseLog))
+	Register("test2json", ParserFunc(parseTestEvents))
+	Register("junit", ParserFunc(parseJUnit))
+	Register("ginkgo", ParserFunc(parseGinkgo))
+}
+
+// Register makes a parser available under a name. It panics if the name is
+// already taken.
+func Register(name string, p Parser) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	if _, exists := parsers[name]; exists {
+		panic(fmt.Sprintf("processor: parser %q registered twice", name))
+	}
+	parsers[name] = p
+}
+
+// LookupParser returns the parser registered under a name, or DefaultParser's
+// if the name is empty
+func LookupParser(name string) (Parser, error) {
+	if name == "" {
+		name = DefaultParser
+	}
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	p, ok := parsers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown parser %q, want one of %s", name, strings.Join(parserNames(), ", "))
+	}
+	return p, nil
+}
+
+// parserNames returns the names of the registered parsers, sorted. The caller
+// must hold parsersMu.
+func parserNames() []string {
+	names := make([]string, 0, len(parsers))
+	for name := range parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,170 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hypershift-community/ci-testgrid/scraper/types"
+)
+
+func TestLookupParser(t *testing.T) {
+	for _, name := range []string{"", "go-test", "test2json", "junit", "ginkgo"} {
+		if _, err := LookupParser(name); err != nil {
+			t.Errorf("LookupParser(%q) returned error: %v", name, err)
+		}
+	}
+	if _, err := LookupParser("nunit"); err == nil || !strings.Contains(err.Error(), "ginkgo, go-test, junit, test2json") {
+		t.Errorf("LookupParser(nunit) error = %v, want the registered parsers", err)
+	}
+}
+
+// parseWith parses a fixture with a registered parser
+func parseWith(t *testing.T, parser, fixture string) map[string]types.Test {
+	t.Helper()
+	p, err := LookupParser(parser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests, err := p.Parse(f)
+	if err != nil {
+		t.Fatalf("%s parser returned error: %v", parser, err)
+	}
+	byName := make(map[string]types.Test)
+	for _, test := range tests {
+		byName[test.Name] = test
+	}
+	if len(byName) != len(tests) {
+		t.Errorf("%s parser returned duplicate tests: %+v", parser, tests)
+	}
+	return byName
+}
+
+// The fixtures in testdata/ginkgo and testdata/junit/ginkgo.xml were recorded
+// from the same Ginkgo v2 suite
+const (
+	ginkgoFailed  = "HostedCluster when nodes join should roll out the control plane"
+	ginkgoPassed  = "HostedCluster when nodes join should report ready nodes"
+	ginkgoSkipped = "HostedCluster should upgrade"
+	ginkgoPending = "HostedCluster should be pending"
+)
+
+func TestParseGinkgo(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    map[string]string
+	}{
+		{
+			// Passed specs are only named in verbose output
+			fixture: "ginkgo/plain.log",
+			want:    map[string]string{ginkgoFailed: "fail", ginkgoPending: "skip"},
+		},
+		{
+			fixture: "ginkgo/verbose.log",
+			want:    map[string]string{ginkgoFailed: "fail", ginkgoPassed: "pass", ginkgoSkipped: "skip", ginkgoPending: "skip"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			results := parseWith(t, "ginkgo", tt.fixture)
+			if len(results) != len(tt.want) {
+				t.Fatalf("parsed %d specs, want %d: %+v", len(results), len(tt.want), results)
+			}
+			for name, result := range tt.want {
+				if got := results[name].Result; got != result {
+					t.Errorf("%s: result = %q, want %q", name, got, result)
+				}
+			}
+
+			failed := results[ginkgoFailed]
+			logs := strings.Join(failed.Logs, "\n")
+			if !strings.HasPrefix(failed.Logs[0], "  ") || !strings.Contains(logs, "[FAILED] Expected") || !strings.Contains(logs, "<string>: Available") {
+				t.Errorf("failed spec logs = %q, want the failure", failed.Logs)
+			}
+			if strings.Contains(logs, "suite_test.go:23\n") || strings.Contains(logs, "[0.000 seconds]") {
+				t.Errorf("failed spec logs = %q, want them without the spec's header", failed.Logs)
+			}
+			if passed, ok := results[ginkgoPassed]; ok && (passed.Duration != 10*time.Millisecond || passed.Logs != nil) {
+				t.Errorf("passed spec = %+v, want 10ms without logs", passed)
+			}
+		})
+	}
+}
+
+func TestParseJUnit(t *testing.T) {
+	t.Run("ginkgo", func(t *testing.T) {
+		results := parseWith(t, "junit", "junit/ginkgo.xml")
+		want := map[string]string{ginkgoFailed: "fail", ginkgoPassed: "pass", ginkgoSkipped: "skip", ginkgoPending: "skip"}
+		if len(results) != len(want) {
+			t.Fatalf("parsed %d tests, want %d: %+v", len(results), len(want), results)
+		}
+		for name, result := range want {
+			if got := results[name].Result; got != result {
+				t.Errorf("%s: result = %q, want %q", name, got, result)
+			}
+		}
+		failed := results[ginkgoFailed]
+		if failed.Duration != 383081*time.Nanosecond || failed.Logs[0] != "[FAILED] Expected" {
+			t.Errorf("failed test = %+v, want its duration and failure", failed)
+		}
+		if !strings.Contains(strings.Join(failed.Logs, "\n"), "STEP: waiting for the kube-apiserver") {
+			t.Errorf("failed test logs = %q, want its output", failed.Logs)
+		}
+		if passed := results[ginkgoPassed]; passed.Logs != nil {
+			t.Errorf("passed test logs = %q, want none", passed.Logs)
+		}
+	})
+
+	t.Run("pytest", func(t *testing.T) {
+		results := parseWith(t, "junit", "junit/pytest.xml")
+		want := map[string]string{
+			"tests.test_upgrade.test_rollback": "fail",
+			"tests.test_upgrade.test_version":  "pass",
+			"tests.test_upgrade.test_nightly":  "skip",
+		}
+		if len(results) != len(want) {
+			t.Fatalf("parsed %d tests, want %d: %+v", len(results), len(want), results)
+		}
+		for name, result := range want {
+			if got := results[name].Result; got != result {
+				t.Errorf("%s: result = %q, want %q", name, got, result)
+			}
+		}
+		failed := results["tests.test_upgrade.test_rollback"]
+		if last := failed.Logs[len(failed.Logs)-1]; last != "tests/test_upgrade.py:6: AssertionError" {
+			t.Errorf("failed test logs end with %q, want the traceback", last)
+		}
+		if skipped := results["tests.test_upgrade.test_nightly"]; len(skipped.Logs) != 1 || !strings.HasSuffix(skipped.Logs[0], "no nightly payload") {
+			t.Errorf("skipped test logs = %q, want the reason", skipped.Logs)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		p, _ := LookupParser("junit")
+		if _, err := p.Parse(strings.NewReader(`<testsuite><testcase name="a">`)); err == nil {
+			t.Error("junit parser accepted a truncated report")
+		}
+	})
+}
+
+func TestParseTestEvents(t *testing.T) {
+	// The test2json parser ignores go test's text output, which the go-test
+	// parser would use without events
+	results := parseWith(t, "test2json", "test2json/subtests.json")
+	if got := results["TestCreateCluster/Main/EnsureNodesReady"].Result; got != "fail" {
+		t.Errorf("TestCreateCluster/Main/EnsureNodesReady: result = %q, want fail", got)
+	}
+	tests, err := parseTestEvents(strings.NewReader(sampleLog))
+	if err != nil || len(tests) != 0 {
+		t.Errorf("parseTestEvents of a text log = %+v, %v, want no tests", tests, err)
+	}
+}
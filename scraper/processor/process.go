@@ -2,33 +2,66 @@ package processor
 
 import (
 	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
 	"io"
-	"log"
-	"net/http"
 	"os"
 	"regexp"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/hypershift-community/ci-testgrid/scraper/httpclient"
+	"github.com/hypershift-community/ci-testgrid/scraper/logging"
 	"github.com/hypershift-community/ci-testgrid/scraper/types"
 )
 
-func ProcessJob(job *types.Job) ([]types.Test, error) {
-	log.Printf("Fetching test log from %s\n", job.LogURL)
-	resp, err := http.Get(job.LogURL)
+// ProcessJob fetches a job's build log and parses its tests with parser. It
+// also records the job's steps, and a failed job without failed tests gets the
+// stage and reason of its failure. It logs with the logger carried by ctx.
+func ProcessJob(ctx context.Context, job *types.Job, parser Parser) ([]types.Test, error) {
+	logger := logging.FromContext(ctx)
+
+	if dir, ok := testArtifactsDir(job.LogURL); ok {
+		job.ArtifactsURL = dir
+		if os.Getenv("SKIP_ARTIFACTS") == "" {
+			steps, err := fetchSteps(ctx, dir)
+			if err != nil {
+				logger.Warn("Error fetching steps", logging.Error, err)
+			}
+			job.Steps = steps
+		}
+	} else {
+		logger.Warn("No test artifacts directory in build log URL", logging.URL, job.LogURL)
+	}
+
+	logger.Info("Fetching test log", logging.URL, job.LogURL)
+	start := time.Now()
+	// The logs of finished builds don't change, so they are cached
+	body, err := httpclient.FromContext(ctx).OpenCached(ctx, job.LogURL)
+	if httpclient.IsNotFound(err) && job.Result == "FAILURE" {
+		// The job failed before the test step ran
+		logger.Info("Build log not found", logging.URL, job.LogURL)
+		classifyFailure(ctx, job, nil, false)
+		return nil, nil
+	}
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("fetching build log: %w", err)
 	}
-	defer resp.Body.Close()
+	defer body.Close()
 
-	tests, err := parseLog(resp.Body)
+	tests, err := parser.Parse(body)
 	if err != nil {
 		return nil, err
 	}
+	logger.Debug("Parsed test log", logging.URL, job.LogURL, "tests", len(tests), logging.Duration, time.Since(start))
+
+	addFailureSignatures(tests)
+	classifyFailure(ctx, job, tests, true)
 
 	if os.Getenv("SKIP_ARTIFACTS") == "" {
-		fetchArtifacts(job.LogURL, tests)
+		fetchArtifacts(ctx, job.LogURL, tests)
 	}
 
 	return tests, nil
@@ -47,21 +80,54 @@ type Tests struct {
 	Items []TestEntry `json:"items"`
 }
 
-// parseLog processes the test log and returns a slice of TestEntry.
+const (
+	// maxLineBytes caps the length of a log line kept in memory. The rest of
+	// longer lines, typically dumped JSON, is dropped.
+	maxLineBytes = 64 * 1024
+	// maxTestLogLines and maxTestLogBytes cap the logs stored per test, which
+	// keeps jobs well below MongoDB's 16MB document limit
+	maxTestLogLines = 20000
+	maxTestLogBytes = 2 * 1024 * 1024
+)
+
+// Regex patterns to detect test start and result lines.
+var (
+	testStartRe = regexp.MustCompile(`^\s*(===)\s+(RUN|CONT|NAME)\s+(\S+)`)
+	resultRe    = regexp.MustCompile(`^\s*(---)\s+(PASS|FAIL|SKIP):\s+(\S+) \(([^\)]+)\)`)
+	resultRe2   = regexp.MustCompile(`^\s*(===)\s+(PASS|FAIL|SKIP): \. (\S+) \(([^\)]+)\)`)
+)
+
+// parseLog processes the test log and returns a slice of TestEntry. Both go
+// test's text output and test2json event streams are understood. The log is
+// read as a stream, and may be gzip-compressed. Lines of any length are
+// handled, but only their first maxLineBytes are kept, and each test's logs
+// are capped at maxTestLogLines and maxTestLogBytes.
 func parseLog(in io.Reader) ([]types.Test, error) {
-	scanner := bufio.NewScanner(in)
+	reader, err := newLogReader(in)
+	if err != nil {
+		return nil, err
+	}
 	// testsMap keeps track of tests by name.
 	testsMap := make(map[string]*types.Test)
 	var currentTest *types.Test
+	caps := make(map[*types.Test]*logCap)
+	events := newTest2jsonParser()
 
-	// Regex patterns to detect test start and result lines.
-	testStartRe := regexp.MustCompile(`^\s*(===)\s+(RUN|CONT|NAME)\s+(\S+)`)
-	resultRe := regexp.MustCompile(`^\s*(---)\s+(PASS|FAIL|SKIP):\s+(\S+) \(([^\)]+)\)`)
-	resultRe2 := regexp.MustCompile(`^\s*(===)\s+(PASS|FAIL|SKIP): \. (\S+) \(([^\)]+)\)`)
-
-	for scanner.Scan() {
-		line := scanner.Text()
+	for {
+		line, err := reader.readLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading build log: %w", err)
+		}
 
+		// Lines starting with '{' are test2json events, e.g. from go test
+		// -json, or dumped JSON
+		if event, ok := parseTestEvent(line); ok {
+			events.handle(event)
+			continue
+		}
 		// Skip lines starting with '+' or '{'
 		if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "{") {
 			continue
@@ -131,11 +197,19 @@ func parseLog(in io.Reader) ([]types.Test, error) {
 			trimmed := strings.TrimSpace(line)
 			// Only add the line if it does NOT start with "---" or "===".
 			if !strings.HasPrefix(trimmed, "---") && !strings.HasPrefix(trimmed, "===") {
-				currentTest.Logs = append(currentTest.Logs, line)
+				appendLogLine(currentTest, caps, line)
 			}
 		}
 	}
 
+	// Test events describe the tests precisely, so they take precedence over
+	// any text output
+	if events.seen() {
+		return events.results(), nil
+	}
+
+	markTruncated(caps)
+
 	// Convert the map to a slice.
 	var tests []types.Test
 	for _, test := range testsMap {
@@ -146,3 +220,103 @@ func parseLog(in io.Reader) ([]types.Test, error) {
 	})
 	return tests, nil
 }
+
+// logCap tracks how much of a test's logs has been stored and dropped
+type logCap struct {
+	bytes        int
+	droppedLines int
+	droppedBytes int
+}
+
+// appendLogLine appends a line to a test's logs, unless its logs have
+// reached maxTestLogLines or maxTestLogBytes
+func appendLogLine(test *types.Test, caps map[*types.Test]*logCap, line string) {
+	c, ok := caps[test]
+	if !ok {
+		c = &logCap{}
+		caps[test] = c
+	}
+	if c.droppedLines > 0 || len(test.Logs) >= maxTestLogLines || c.bytes+len(line) > maxTestLogBytes {
+		c.droppedLines++
+		c.droppedBytes += len(line)
+		return
+	}
+	test.Logs = append(test.Logs, line)
+	c.bytes += len(line)
+}
+
+// markTruncated appends a marker to the logs that were truncated
+func markTruncated(caps map[*types.Test]*logCap) {
+	for test, c := range caps {
+		if c.droppedLines > 0 {
+			test.Logs = append(test.Logs, fmt.Sprintf("... [%d more log lines (%d bytes) truncated]", c.droppedLines, c.droppedBytes))
+		}
+	}
+}
+
+// logReader reads the lines of a build log
+type logReader struct {
+	r *bufio.Reader
+}
+
+// newLogReader returns a reader of the lines of a log, decompressing it if it
+// starts with the gzip magic number
+func newLogReader(in io.Reader) (*logReader, error) {
+	r, err := decompress(in)
+	if err != nil {
+		return nil, err
+	}
+	return &logReader{r: bufio.NewReaderSize(r, maxLineBytes)}, nil
+}
+
+// decompress returns a reader of a log's content, which is decompressed if it
+// starts with the gzip magic number
+func decompress(in io.Reader) (io.Reader, error) {
+	br := bufio.NewReaderSize(in, maxLineBytes)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("reading gzip header: %w", err)
+		}
+		return gz, nil
+	}
+	return br, nil
+}
+
+// readLine returns the next line without its line ending, or io.EOF after
+// the last line. Lines longer than maxLineBytes are cut off with a marker.
+func (l *logReader) readLine() (string, error) {
+	var line []byte
+	dropped := 0
+	for {
+		chunk, err := l.r.ReadSlice('\n')
+		if len(line) < maxLineBytes {
+			keep := min(len(chunk), maxLineBytes-len(line))
+			line = append(line, chunk[:keep]...)
+			dropped += len(chunk) - keep
+		} else {
+			dropped += len(chunk)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF && (len(line) > 0 || dropped > 0) {
+			err = nil
+		} else if err == nil {
+			// Drop the line ending, which was either kept or dropped
+			if dropped > 0 {
+				dropped--
+			} else {
+				line = line[:len(line)-1]
+			}
+		}
+		if err != nil {
+			return "", err
+		}
+		text := strings.TrimSuffix(string(line), "\r")
+		if dropped > 0 {
+			text += fmt.Sprintf(" ... [%d bytes truncated]", dropped)
+		}
+		return text, nil
+	}
+}
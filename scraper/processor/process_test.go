@@ -0,0 +1,151 @@
+package processor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+const sampleLog = `+ make e2e
+=== RUN   TestCreateCluster
+=== RUN   TestCreateCluster/Main
+--- FAIL: TestCreateCluster/Main (12.50s)
+--- FAIL: TestCreateCluster (20.00s)
+--- PASS: TestNodePool (5.00s)
+--- SKIP: TestUpgrade (0.00s)
+=== FAIL: . TestCreateCluster/Main (12.50s)
+    util.go:42: failed to wait for nodes
+    util.go:43: context deadline exceeded
+=== FAIL: . TestCreateCluster (20.00s)
+    create_test.go:10: cluster never became available
+FAIL
+`
+
+func TestParseLog(t *testing.T) {
+	tests, err := parseLog(strings.NewReader(sampleLog))
+	if err != nil {
+		t.Fatalf("parseLog returned error: %v", err)
+	}
+
+	want := map[string]struct {
+		result string
+		logs   []string
+	}{
+		"TestCreateCluster":      {"fail", []string{"    create_test.go:10: cluster never became available"}},
+		"TestCreateCluster/Main": {"fail", []string{"    util.go:42: failed to wait for nodes", "    util.go:43: context deadline exceeded"}},
+		"TestNodePool":           {"pass", nil},
+		"TestUpgrade":            {"skip", nil},
+	}
+	if len(tests) != len(want) {
+		t.Fatalf("parseLog returned %d tests, want %d: %+v", len(tests), len(want), tests)
+	}
+	for _, test := range tests {
+		w, ok := want[test.Name]
+		if !ok {
+			t.Errorf("unexpected test %q", test.Name)
+			continue
+		}
+		if test.Result != w.result {
+			t.Errorf("%s: result = %q, want %q", test.Name, test.Result, w.result)
+		}
+		if strings.Join(test.Logs, "\n") != strings.Join(w.logs, "\n") {
+			t.Errorf("%s: logs = %q, want %q", test.Name, test.Logs, w.logs)
+		}
+	}
+}
+
+func TestParseLogLongLines(t *testing.T) {
+	long := "    " + strings.Repeat("x", 3*maxLineBytes)
+	log := "=== FAIL: . TestA (1.00s)\n" + long + "\n    after the long line\n--- FAIL: TestA (1.00s)\n"
+
+	tests, err := parseLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("parseLog returned error: %v", err)
+	}
+	if len(tests) != 1 || len(tests[0].Logs) != 2 {
+		t.Fatalf("parseLog returned %+v, want TestA with 2 log lines", tests)
+	}
+	wantMarker := fmt.Sprintf(" ... [%d bytes truncated]", len(long)-maxLineBytes)
+	if first := tests[0].Logs[0]; !strings.HasSuffix(first, wantMarker) || len(first) != maxLineBytes+len(wantMarker) {
+		t.Errorf("long line was not truncated with marker %q, got %d bytes ending in %q", wantMarker, len(first), first[len(first)-40:])
+	}
+	if tests[0].Logs[1] != "    after the long line" {
+		t.Errorf("line after the long line = %q", tests[0].Logs[1])
+	}
+}
+
+func TestParseLogGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(sampleLog))
+	gz.Close()
+
+	tests, err := parseLog(&buf)
+	if err != nil {
+		t.Fatalf("parseLog returned error: %v", err)
+	}
+	if len(tests) != 4 {
+		t.Errorf("parseLog returned %d tests from gzipped log, want 4", len(tests))
+	}
+}
+
+func TestParseLogTruncatesTestLogs(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("=== FAIL: . TestA (1.00s)\n")
+	extra := 10
+	for i := 0; i < maxTestLogLines+extra; i++ {
+		fmt.Fprintf(&b, "    line %d\n", i)
+	}
+
+	tests, err := parseLog(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("parseLog returned error: %v", err)
+	}
+	logs := tests[0].Logs
+	if len(logs) != maxTestLogLines+1 {
+		t.Fatalf("stored %d log lines, want %d and a marker", len(logs), maxTestLogLines)
+	}
+	if marker := logs[len(logs)-1]; !strings.HasPrefix(marker, fmt.Sprintf("... [%d more log lines", extra)) {
+		t.Errorf("truncation marker = %q", marker)
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestParseLogReportsReadErrors(t *testing.T) {
+	in := io.MultiReader(strings.NewReader(sampleLog), errReader{})
+	if _, err := parseLog(in); err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Errorf("parseLog error = %v, want the read error", err)
+	}
+}
+
+func BenchmarkParseLog(b *testing.B) {
+	var buf bytes.Buffer
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&buf, "=== RUN   TestCase%d\n--- FAIL: TestCase%d (1.00s)\n", i, i)
+	}
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&buf, "=== FAIL: . TestCase%d (1.00s)\n", i)
+		for j := 0; j < 1000; j++ {
+			fmt.Fprintf(&buf, "    util.go:%d: 2025-03-04T10:11:12Z waiting for condition on node %d\n", j, j)
+		}
+		fmt.Fprintf(&buf, "    dump: %s\n", strings.Repeat(`{"key":"value"},`, 20000))
+	}
+	log := buf.Bytes()
+
+	b.SetBytes(int64(len(log)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseLog(bytes.NewReader(log)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package processor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"github.com/hypershift-community/ci-testgrid/scraper/types"
+)
+
+const (
+	// maxSignatureLines is how many failure lines make up a signature.
+	maxSignatureLines = 3
+	// maxSignatureTextLen caps the stored example text of a signature.
+	maxSignatureTextLen = 300
+)
+
+// signatureReplacements normalize the parts of a log line that vary between
+// runs of the same failure. They are applied in order, so more specific
+// patterns come first.
+var signatureReplacements = []struct {
+	re          *regexp.Regexp
+	replacement string
+}{
+	// Timestamps, e.g. 2025-01-02T15:04:05.123Z or 2025-01-02 15:04:05
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`), "<TS>"},
+	{regexp.MustCompile(`\b\d{2}:\d{2}:\d{2}(\.\d+)?\b`), "<TS>"},
+	// UUIDs
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<UUID>"},
+	// IPv4 addresses, with an optional port
+	{regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}(:\d+)?\b`), "<IP>"},
+	// Generated Kubernetes names, such as those of e2e clusters and their
+	// namespaces: a prefix ending the name with the five-character random
+	// suffix of generateName, drawn from its vowel-free alphabet, e.g.
+	// example-x7k2p. The character after the name is kept.
+	{regexp.MustCompile(`\b[a-z][a-z0-9]*-([a-z0-9]+-)*[bcdfghjklmnpqrstvwxz2456789]{5}([^a-z0-9-]|$)`), "<NAME>$2"},
+	// Source line numbers in Go test output, e.g. util.go:123
+	{regexp.MustCompile(`\.go:\d+`), ".go:<N>"},
+	// Long hex strings such as commit SHAs and hashes
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8,}\b`), "<HEX>"},
+	// Durations, e.g. 1m30.5s or 250ms
+	{regexp.MustCompile(`\b(\d+(\.\d+)?(h|m|s|ms|µs|us|ns))+\b`), "<DUR>"},
+	// Any remaining numbers
+	{regexp.MustCompile(`\b\d+(\.\d+)?\b`), "<N>"},
+}
+
+// failureLineRe matches log lines that describe a failure.
+var failureLineRe = regexp.MustCompile(`(?i)error|fail|expected|timed out|timeout|panic|unable to|cannot|deadline exceeded`)
+
+// normalizeLogLine replaces the run-specific parts of a log line with
+// placeholders.
+func normalizeLogLine(line string) string {
+	line = strings.TrimSpace(line)
+	for _, r := range signatureReplacements {
+		line = r.re.ReplaceAllString(line, r.replacement)
+	}
+	return strings.Join(strings.Fields(line), " ")
+}
+
+// FailureSignature derives a signature from a failed test's logs. It is built
+// from the first few normalized lines that look like failures, or the first
+// few non-empty lines if none do, so that the same root cause hashes to the
+// same signature across jobs. It returns the hex-encoded hash and the
+// normalized lines it was computed from.
+func FailureSignature(logs []string) (string, string) {
+	var failureLines, otherLines []string
+	for _, line := range logs {
+		normalized := normalizeLogLine(line)
+		if normalized == "" {
+			continue
+		}
+		if failureLineRe.MatchString(normalized) {
+			if len(failureLines) < maxSignatureLines {
+				failureLines = append(failureLines, normalized)
+			}
+		} else if len(otherLines) < maxSignatureLines {
+			otherLines = append(otherLines, normalized)
+		}
+	}
+
+	lines := failureLines
+	if len(lines) == 0 {
+		lines = otherLines
+	}
+	if len(lines) == 0 {
+		return "", ""
+	}
+
+	text := strings.Join(lines, "\n")
+	sum := sha256.Sum256([]byte(text))
+	if runes := []rune(text); len(runes) > maxSignatureTextLen {
+		text = string(runes[:maxSignatureTextLen])
+	}
+	return hex.EncodeToString(sum[:])[:16], text
+}
+
+// addFailureSignatures sets the signature of every failed test.
+func addFailureSignatures(tests []types.Test) {
+	for i := range tests {
+		if tests[i].Result != "fail" {
+			continue
+		}
+		tests[i].Signature, tests[i].SignatureText = FailureSignature(tests[i].Logs)
+	}
+}
@@ -0,0 +1,99 @@
+package processor
+
+import "testing"
+
+func TestNormalizeLogLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{
+			name: "timestamp and source line",
+			line: "    util.go:123: 2025-03-04T10:11:12.345Z failed to wait for nodes",
+			want: "util.go:<N>: <TS> failed to wait for nodes",
+		},
+		{
+			name: "uuid and ip",
+			line: "request 3f2b8c4e-1a2b-4c3d-9e8f-0123456789ab to 10.0.12.7:6443 timed out",
+			want: "request <UUID> to <IP> timed out",
+		},
+		{
+			name: "generated cluster name",
+			line: "hostedcluster e2e-clusters-x7k2p/example-b4lz9 is not available",
+			want: "hostedcluster <NAME>/<NAME> is not available",
+		},
+		{
+			name: "generated pod name",
+			line: "pod control-plane-operator-7d9f8c6b5-x7k2p is not ready",
+			want: "pod <NAME> is not ready",
+		},
+		{
+			name: "generated name at the end of the line",
+			line: "failed to delete namespace e2e-clusters-x7k2p",
+			want: "failed to delete namespace <NAME>",
+		},
+		{
+			name: "suffix longer than generated",
+			line: "error from sync-bcdfghj",
+			want: "error from sync-bcdfghj",
+		},
+		{
+			name: "suffix shorter than generated",
+			line: "error from e2e-sync-fwd",
+			want: "error from e2e-sync-fwd",
+		},
+		{
+			name: "suffix followed by more of the name",
+			line: "resource example-b4lz9-config not found",
+			want: "resource example-b4lz9-config not found",
+		},
+		{
+			name: "generated name in a domain",
+			line: "dial tcp: lookup api.example-b4lz9.hypershift.local",
+			want: "dial tcp: lookup api.<NAME>.hypershift.local",
+		},
+		{
+			name: "hyphenated words",
+			line: "kube-apiserver is not ready, dry-run failed",
+			want: "kube-apiserver is not ready, dry-run failed",
+		},
+		{
+			name: "plain words are kept",
+			line: "expected node-pools to be ready",
+			want: "expected node-pools to be ready",
+		},
+		{
+			name: "durations and numbers",
+			line: "condition not met after 10m0s, 3 of 5 nodes ready",
+			want: "condition not met after <DUR>, <N> of <N> nodes ready",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeLogLine(tt.line); got != tt.want {
+				t.Errorf("normalizeLogLine(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailureSignatureIgnoresRunSpecificDetails(t *testing.T) {
+	a, _ := FailureSignature([]string{
+		"    eventually.go:104: 2025-03-04T10:11:12Z Failed to wait for HostedCluster e2e-clusters-x7k2p/example-b4lz9 to rollout in 10m0s: context deadline exceeded",
+	})
+	b, _ := FailureSignature([]string{
+		"    eventually.go:110: 2025-03-05T01:02:03Z Failed to wait for HostedCluster e2e-clusters-q9w8r/example-zzt6m to rollout in 10m0s: context deadline exceeded",
+	})
+	c, _ := FailureSignature([]string{
+		"    eventually.go:104: 2025-03-04T10:11:12Z Failed to create NodePool: quota exceeded",
+	})
+
+	if a == "" || a != b {
+		t.Errorf("expected matching signatures for the same failure, got %q and %q", a, b)
+	}
+	if a == c {
+		t.Errorf("expected different signatures for different failures, both were %q", a)
+	}
+}
@@ -0,0 +1,106 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hypershift-community/ci-testgrid/scraper/httpclient"
+	"github.com/hypershift-community/ci-testgrid/scraper/logging"
+	"github.com/hypershift-community/ci-testgrid/scraper/types"
+)
+
+// stepMetadata is the content of a step's started.json or finished.json
+type stepMetadata struct {
+	Timestamp int64  `json:"timestamp"` // Unix seconds
+	Passed    *bool  `json:"passed"`
+	Result    string `json:"result"`
+}
+
+// testArtifactsDir returns the URL of the directory holding the artifacts of
+// the steps of a job's test, i.e. artifacts/<test>/, from the URL of one of
+// the test's logs
+func testArtifactsDir(logURL string) (string, bool) {
+	root, ok := jobArtifactsRoot(logURL)
+	if !ok {
+		return "", false
+	}
+	test, _, ok := strings.Cut(strings.TrimPrefix(logURL, root+"artifacts/"), "/")
+	if !ok || test == "" {
+		return "", false
+	}
+	return root + "artifacts/" + test + "/", true
+}
+
+// fetchSteps returns the steps of a job's test, in the order they started,
+// from the test's artifacts directory. Each step has a directory there with a
+// started.json and, once it finished, a finished.json. Directories without
+// started.json aren't steps.
+func fetchSteps(ctx context.Context, dir string) ([]types.Step, error) {
+	entries, err := listGCSDirectory(ctx, dir)
+	if err != nil {
+		return nil, fmt.Errorf("listing steps: %w", err)
+	}
+
+	var steps []types.Step
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry, "/") {
+			continue
+		}
+		name := strings.TrimSuffix(entry, "/")
+		started, err := fetchStepMetadata(ctx, dir+entry+"started.json")
+		if httpclient.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			logging.FromContext(ctx).Warn("Error fetching step", "step", name, logging.Error, err)
+			continue
+		}
+
+		step := types.Step{
+			Name:      name,
+			Result:    "UNKNOWN",
+			StartedAt: time.Unix(started.Timestamp, 0).UTC().Format(time.RFC3339),
+		}
+		finished, err := fetchStepMetadata(ctx, dir+entry+"finished.json")
+		switch {
+		case err == nil:
+			step.Result = finished.Result
+			if step.Result == "" && finished.Passed != nil {
+				step.Result = "FAILURE"
+				if *finished.Passed {
+					step.Result = "SUCCESS"
+				}
+			}
+			if finished.Timestamp >= started.Timestamp {
+				step.Duration = time.Duration(finished.Timestamp-started.Timestamp) * time.Second
+			}
+		case !httpclient.IsNotFound(err):
+			logging.FromContext(ctx).Warn("Error fetching step result", "step", name, logging.Error, err)
+		}
+		steps = append(steps, step)
+	}
+
+	// RFC3339 timestamps in UTC sort chronologically
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].StartedAt < steps[j].StartedAt
+	})
+	return steps, nil
+}
+
+// fetchStepMetadata fetches and decodes a step's started.json or
+// finished.json
+func fetchStepMetadata(ctx context.Context, url string) (stepMetadata, error) {
+	var metadata stepMetadata
+	body, err := httpclient.FromContext(ctx).GetCached(ctx, url)
+	if err != nil {
+		return metadata, err
+	}
+	if err := json.Unmarshal(body, &metadata); err != nil {
+		return metadata, fmt.Errorf("decoding %s: %w", url, err)
+	}
+	return metadata, nil
+}
@@ -0,0 +1,53 @@
+package processor
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hypershift-community/ci-testgrid/scraper/types"
+)
+
+func TestFetchSteps(t *testing.T) {
+	ctx, root := serveGCSWeb(t, filepath.Join("testdata", "steps"))
+	steps, err := fetchSteps(ctx, root+"artifacts/e2e-aws/")
+	if err != nil {
+		t.Fatalf("fetchSteps returned error: %v", err)
+	}
+	want := []types.Step{
+		{Name: "ipi-install-rbac", Result: "SUCCESS", StartedAt: "2025-10-18T09:00:00Z", Duration: 12 * time.Second},
+		{Name: "ipi-install-install", Result: "SUCCESS", StartedAt: "2025-10-18T09:00:12Z", Duration: 39*time.Minute + 58*time.Second},
+		{Name: "hypershift-install", Result: "SUCCESS", StartedAt: "2025-10-18T09:40:10Z", Duration: 5*time.Minute + 15*time.Second},
+		{Name: "hypershift-aws-run-e2e-nested", Result: "FAILURE", StartedAt: "2025-10-18T09:45:25Z", Duration: time.Hour + 16*time.Minute + 36*time.Second},
+		// The step was cut off, so it never finished
+		{Name: "hypershift-dump", Result: "UNKNOWN", StartedAt: "2025-10-18T11:02:01Z"},
+		{Name: "ipi-deprovision-deprovision", Result: "SUCCESS", StartedAt: "2025-10-18T11:02:20Z", Duration: 12*time.Minute + 41*time.Second},
+	}
+	if len(steps) != len(want) {
+		t.Fatalf("fetchSteps returned %d steps, want %d: %+v", len(steps), len(want), steps)
+	}
+	for i := range want {
+		if steps[i] != want[i] {
+			t.Errorf("step %d = %+v, want %+v", i, steps[i], want[i])
+		}
+	}
+}
+
+func TestTestArtifactsDir(t *testing.T) {
+	const root = "https://gcsweb-ci.apps.ci.l2s4.p1.openshiftapps.com/gcs/test-platform-results/pr-logs/pull/openshift_hypershift/5021/pull-ci-openshift-hypershift-main-e2e-aws/1979000000000000000/"
+	tests := []struct {
+		logURL string
+		want   string
+	}{
+		{root + "artifacts/e2e-aws/hypershift-aws-run-e2e-nested/build-log.txt", root + "artifacts/e2e-aws/"},
+		{root + "artifacts/e2e-aws/", root + "artifacts/e2e-aws/"},
+		{root + "artifacts/e2e-aws", ""},
+		{root + "build-log.txt", ""},
+	}
+	for _, tt := range tests {
+		got, ok := testArtifactsDir(tt.logURL)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("testArtifactsDir(%q) = %q, %v, want %q", tt.logURL, got, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/hypershift-community/ci-testgrid/scraper/types"
+)
+
+// testEvent is an event emitted by test2json, e.g. by go test -json
+type testEvent struct {
+	Time       time.Time
+	Action     string
+	Package    string
+	Test       string
+	Elapsed    float64 // seconds
+	Output     string
+	OutputType string // "frame" for the === and --- lines, since Go 1.25
+}
+
+// parseTestEvent parses a log line as a test2json event. It reports false for
+// lines that aren't events.
+func parseTestEvent(line string) (testEvent, bool) {
+	if !strings.HasPrefix(line, "{") || !strings.Contains(line, `"Action":`) {
+		return testEvent{}, false
+	}
+	var event testEvent
+	if err := json.Unmarshal([]byte(line), &event); err != nil || event.Action == "" {
+		return testEvent{}, false
+	}
+	return event, true
+}
+
+// parseTestEvents returns the tests of a test2json event stream, ignoring any
+// lines that aren't events
+func parseTestEvents(in io.Reader) ([]types.Test, error) {
+	reader, err := newLogReader(in)
+	if err != nil {
+		return nil, err
+	}
+	events := newTest2jsonParser()
+	for {
+		line, err := reader.readLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading build log: %w", err)
+		}
+		if event, ok := parseTestEvent(line); ok {
+			events.handle(event)
+		}
+	}
+	return events.results(), nil
+}
+
+// test2jsonTest accumulates the events of a test
+type test2jsonTest struct {
+	test     types.Test
+	lastRun  string // The last of the run, pause and cont actions
+	finished bool
+	partial  string // Output not terminated by a newline yet
+}
+
+// test2jsonParser reconstructs test results from a test2json event stream
+type test2jsonParser struct {
+	tests map[[2]string]*test2jsonTest // By package and test name
+	// order lists tests in the order they started, so parents come before
+	// their subtests
+	order         [][2]string
+	failedPackage map[string]bool
+	caps          map[*types.Test]*logCap
+}
+
+func newTest2jsonParser() *test2jsonParser {
+	return &test2jsonParser{
+		tests:         make(map[[2]string]*test2jsonTest),
+		failedPackage: make(map[string]bool),
+		caps:          make(map[*types.Test]*logCap),
+	}
+}
+
+// seen reports whether any test events were handled
+func (p *test2jsonParser) seen() bool {
+	return len(p.order) > 0
+}
+
+// handle processes an event
+func (p *test2jsonParser) handle(event testEvent) {
+	if event.Test == "" {
+		// Package level event
+		if event.Action == "fail" {
+			p.failedPackage[event.Package] = true
+		}
+		return
+	}
+
+	key := [2]string{event.Package, event.Test}
+	t, ok := p.tests[key]
+	if !ok {
+		t = &test2jsonTest{test: types.Test{Name: event.Test}}
+		p.tests[key] = t
+		p.order = append(p.order, key)
+	}
+
+	switch event.Action {
+	case "run", "pause", "cont":
+		t.lastRun = event.Action
+	case "pass", "fail", "skip":
+		t.test.Result = event.Action
+		t.test.Duration = time.Duration(event.Elapsed * float64(time.Second))
+		t.finished = true
+	case "output":
+		p.output(t, event)
+	}
+}
+
+// output adds the complete lines of an output event to a test's logs,
+// leaving out the === and --- lines that frame the test's output
+func (p *test2jsonParser) output(t *test2jsonTest, event testEvent) {
+	if event.OutputType == "frame" {
+		return
+	}
+	text := t.partial + event.Output
+	lines := strings.Split(text, "\n")
+	t.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		if isFrameLine(line) {
+			continue
+		}
+		appendLogLine(&t.test, p.caps, line)
+	}
+}
+
+// isFrameLine reports whether an output line is one of the lines go test
+// prints around a test's output
+func isFrameLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range []string{"=== RUN", "=== PAUSE", "=== CONT", "=== NAME", "--- PASS:", "--- FAIL:", "--- SKIP:"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// results returns the tests in the order they started. Tests that were still
+// running when their package failed, e.g. on a timeout or panic, or when the
+// stream ended, e.g. because the job was killed, are failed. Tests that were
+// paused and never continued didn't run, so they are left out. Only failed
+// and skipped tests keep their logs. Tests whose name was run in more than
+// one package are named <package>.<test>, so they don't collide.
+func (p *test2jsonParser) results() []types.Test {
+	for _, t := range p.tests {
+		if t.partial != "" {
+			appendLogLine(&t.test, p.caps, t.partial)
+			t.partial = ""
+		}
+	}
+	markTruncated(p.caps)
+
+	packages := make(map[string]int)
+	for _, key := range p.order {
+		packages[key[1]]++
+	}
+
+	var tests []types.Test
+	for _, key := range p.order {
+		t := p.tests[key]
+		if !t.finished {
+			if t.lastRun == "pause" {
+				continue
+			}
+			if !p.failedPackage[key[0]] {
+				t.test.Logs = append(t.test.Logs, "(the log ended before the test finished)")
+			}
+			t.test.Result = "fail"
+		}
+		if t.test.Result == "pass" {
+			t.test.Logs = nil
+		}
+		if packages[key[1]] > 1 {
+			t.test.Name = key[0] + "." + key[1]
+		}
+		tests = append(tests, t.test)
+	}
+	return tests
+}
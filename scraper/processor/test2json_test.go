@@ -0,0 +1,172 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The fixtures in testdata/test2json were recorded with go test -json
+func parseFixture(t *testing.T, name string) map[string]testResult {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", "test2json", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests, err := parseLog(f)
+	if err != nil {
+		t.Fatalf("parseLog returned error: %v", err)
+	}
+	results := make(map[string]testResult)
+	for i, test := range tests {
+		results[test.Name] = testResult{index: i, result: test.Result, duration: test.Duration, logs: test.Logs}
+	}
+	return results
+}
+
+type testResult struct {
+	index    int
+	result   string
+	duration time.Duration
+	logs     []string
+}
+
+func TestParseTest2json(t *testing.T) {
+	for _, fixture := range []string{"subtests.json", "mixed.log"} {
+		t.Run(fixture, func(t *testing.T) {
+			results := parseFixture(t, fixture)
+
+			want := map[string]string{
+				"TestCreateCluster":                         "fail",
+				"TestCreateCluster/Main":                    "fail",
+				"TestCreateCluster/Main/EnsureNodesReady":   "fail",
+				"TestCreateCluster/Main/EnsureAPIReachable": "pass",
+				"TestCreateCluster/Teardown":                "pass",
+				"TestNodePool":                              "pass",
+				"TestUpgrade":                               "skip",
+			}
+			if len(results) != len(want) {
+				t.Fatalf("parsed %d tests, want %d: %+v", len(results), len(want), results)
+			}
+			for name, result := range want {
+				if got := results[name].result; got != result {
+					t.Errorf("%s: result = %q, want %q", name, got, result)
+				}
+			}
+
+			// Parents are listed before their subtests
+			if results["TestCreateCluster"].index > results["TestCreateCluster/Main"].index ||
+				results["TestCreateCluster/Main"].index > results["TestCreateCluster/Main/EnsureNodesReady"].index {
+				t.Errorf("subtests listed before their parents: %+v", results)
+			}
+
+			if got := results["TestCreateCluster"].duration; got != 30*time.Millisecond {
+				t.Errorf("TestCreateCluster duration = %v, want 30ms", got)
+			}
+
+			failed := results["TestCreateCluster/Main/EnsureNodesReady"].logs
+			wantLogs := []string{
+				"    e2e_test.go:13: waiting for 3 nodes",
+				"    e2e_test.go:14: failed to wait for nodes: context deadline exceeded",
+			}
+			if strings.Join(failed, "\n") != strings.Join(wantLogs, "\n") {
+				t.Errorf("EnsureNodesReady logs = %q, want %q", failed, wantLogs)
+			}
+			if logs := results["TestNodePool"].logs; logs != nil {
+				t.Errorf("passed test kept its logs: %q", logs)
+			}
+			if logs := results["TestUpgrade"].logs; len(logs) != 1 || !strings.Contains(logs[0], "upgrade tests disabled") {
+				t.Errorf("TestUpgrade logs = %q, want the skip reason", logs)
+			}
+		})
+	}
+}
+
+func TestParseTest2jsonTimeout(t *testing.T) {
+	results := parseFixture(t, "timeout.json")
+
+	hang, ok := results["TestHang"]
+	if !ok || hang.result != "fail" {
+		t.Fatalf("TestHang = %+v, want a failure", hang)
+	}
+	if len(hang.logs) < 2 || hang.logs[1] != "panic: test timed out after 1s" {
+		t.Errorf("TestHang logs don't include the timeout panic: %q", hang.logs)
+	}
+	// TestNodePool was paused and never continued, so it didn't run
+	if _, ok := results["TestNodePool"]; ok {
+		t.Errorf("TestNodePool, which never ran, was reported: %+v", results["TestNodePool"])
+	}
+}
+
+func TestParseTest2jsonEndedEarly(t *testing.T) {
+	// The job was killed while TestUpgrade was running, so the package never failed
+	stream := `{"Action":"start","Package":"example.com/e2e"}
+{"Action":"run","Package":"example.com/e2e","Test":"TestNodePool"}
+{"Action":"pass","Package":"example.com/e2e","Test":"TestNodePool","Elapsed":1}
+{"Action":"run","Package":"example.com/e2e","Test":"TestUpgrade"}
+{"Action":"output","Package":"example.com/e2e","Test":"TestUpgrade","Output":"    upgrade_test.go:20: waiting for the control plane to roll out\n"}
+`
+	tests, err := parseLog(strings.NewReader(stream))
+	if err != nil {
+		t.Fatalf("parseLog returned error: %v", err)
+	}
+	if len(tests) != 2 || tests[0].Name != "TestNodePool" || tests[0].Result != "pass" {
+		t.Fatalf("tests = %+v, want TestNodePool and TestUpgrade", tests)
+	}
+	upgrade := tests[1]
+	if upgrade.Name != "TestUpgrade" || upgrade.Result != "fail" {
+		t.Errorf("TestUpgrade = %+v, want a failure", upgrade)
+	}
+	if len(upgrade.Logs) != 2 || !strings.Contains(upgrade.Logs[1], "log ended before the test finished") {
+		t.Errorf("TestUpgrade logs = %q, want a note that the log ended", upgrade.Logs)
+	}
+}
+
+func TestParseTest2jsonPackages(t *testing.T) {
+	stream := `{"Action":"run","Package":"example.com/e2e/aws","Test":"TestNodePool"}
+{"Action":"run","Package":"example.com/e2e/aws","Test":"TestCreateCluster"}
+{"Action":"run","Package":"example.com/e2e/azure","Test":"TestNodePool"}
+{"Action":"output","Package":"example.com/e2e/azure","Test":"TestNodePool","Output":"    nodepool_test.go:8: no nodes\n"}
+{"Action":"fail","Package":"example.com/e2e/azure","Test":"TestNodePool","Elapsed":2}
+{"Action":"pass","Package":"example.com/e2e/aws","Test":"TestNodePool","Elapsed":1}
+{"Action":"pass","Package":"example.com/e2e/aws","Test":"TestCreateCluster","Elapsed":3}
+`
+	tests, err := parseLog(strings.NewReader(stream))
+	if err != nil {
+		t.Fatalf("parseLog returned error: %v", err)
+	}
+	var got []string
+	for _, test := range tests {
+		got = append(got, test.Name+" "+test.Result)
+	}
+	// Only names run in more than one package are qualified
+	want := []string{
+		"example.com/e2e/aws.TestNodePool pass",
+		"TestCreateCluster pass",
+		"example.com/e2e/azure.TestNodePool fail",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("tests = %q, want %q", got, want)
+	}
+}
+
+func TestParseTestEvent(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{`{"Time":"2026-10-18T23:52:08Z","Action":"run","Package":"p","Test":"TestA"}`, true},
+		{`{"kind":"HostedCluster","status":{"conditions":[]}}`, false},
+		{`{"Action":`, false},
+		{`    {"Action":"run"}`, false},
+	}
+	for _, tt := range tests {
+		if _, got := parseTestEvent(tt.line); got != tt.want {
+			t.Errorf("parseTestEvent(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
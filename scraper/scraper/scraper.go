@@ -1,17 +1,22 @@
 package scraper
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/hypershift-community/ci-testgrid/scraper/httpclient"
 	"github.com/hypershift-community/ci-testgrid/scraper/types"
 )
 
+// DefaultGCSWebURL is the base URL of gcsweb, which serves the GCS bucket
+// holding the jobs' artifacts
+const DefaultGCSWebURL = "https://gcsweb-ci.apps.ci.l2s4.p1.openshiftapps.com"
+
 type Pull struct {
 	Number     int    `json:"number"`
 	Author     string `json:"author"`
@@ -42,14 +47,17 @@ type Build struct {
 	Refs         Refs   `json:"Refs"`
 }
 
-func ScrapeJobs(url, suffix string) ([]types.Job, string, error) {
-	resp, err := http.Get(url)
+// ScrapeJobs scrapes the finished builds of a Prow job history page, with the
+// URL of the build log at suffix in each build's artifacts on gcsweb, and
+// returns them with the URL of the page of older runs, if any
+func ScrapeJobs(ctx context.Context, pageURL, gcswebURL, suffix string) ([]types.Job, string, error) {
+	body, err := httpclient.FromContext(ctx).Open(ctx, pageURL)
 	if err != nil {
 		return nil, "", err
 	}
-	defer resp.Body.Close()
+	defer body.Close()
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	doc, err := goquery.NewDocumentFromReader(body)
 	if err != nil {
 		return nil, "", err
 	}
@@ -80,13 +88,15 @@ func ScrapeJobs(url, suffix string) ([]types.Job, string, error) {
 		if build.Result != "SUCCESS" && build.Result != "FAILURE" {
 			continue
 		}
-		logURL, err := spyglassToLogURL(build.SpyglassLink, suffix)
+		logURL, err := spyglassToLogURL(build.SpyglassLink, gcswebURL, suffix)
 		if err != nil {
 			logURL = ""
 		}
 		var pr int
+		var sha string
 		for _, pull := range build.Refs.Pulls {
 			pr = pull.Number
+			sha = pull.SHA
 			break
 		}
 		jobs = append(jobs, types.Job{
@@ -96,18 +106,16 @@ func ScrapeJobs(url, suffix string) ([]types.Job, string, error) {
 			StartedAt: build.Started,
 			LogURL:    logURL,
 			PR:        pr,
+			SHA:       sha,
 			JobLink:   build.SpyglassLink,
 		})
 	}
 
-	return jobs, getOlderRunsURL(doc), nil
+	return jobs, getOlderRunsURL(doc, pageURL), nil
 }
 
-func spyglassToLogURL(spyglassLink, suffix string) (string, error) {
-	const (
-		prefix       = "/view/gs/"
-		gcswebPrefix = "https://gcsweb-ci.apps.ci.l2s4.p1.openshiftapps.com/gcs/"
-	)
+func spyglassToLogURL(spyglassLink, gcswebURL, suffix string) (string, error) {
+	const prefix = "/view/gs/"
 
 	if !strings.HasPrefix(spyglassLink, prefix) {
 		return "", fmt.Errorf("invalid SpyglassLink: missing %q prefix", prefix)
@@ -117,11 +125,13 @@ func spyglassToLogURL(spyglassLink, suffix string) (string, error) {
 	gcsPath := strings.TrimPrefix(spyglassLink, prefix)
 
 	// Construct the final log URL
-	logURL := gcswebPrefix + gcsPath + suffix
+	logURL := gcswebURL + "/gcs/" + gcsPath + suffix
 	return logURL, nil
 }
 
-func getOlderRunsURL(doc *goquery.Document) string {
+// getOlderRunsURL returns the URL of the "Older Runs" link of the page at
+// pageURL
+func getOlderRunsURL(doc *goquery.Document, pageURL string) string {
 	var olderRunsURL string
 	// Find the link with text "<- Older Runs"
 	doc.Find("a").EachWithBreak(func(i int, s *goquery.Selection) bool {
@@ -138,5 +148,16 @@ func getOlderRunsURL(doc *goquery.Document) string {
 		return true // keep looking
 	})
 
-	return "https://prow.ci.openshift.org" + olderRunsURL
+	if olderRunsURL == "" {
+		return ""
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	ref, err := url.Parse(olderRunsURL)
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(ref).String()
 }
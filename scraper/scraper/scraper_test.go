@@ -0,0 +1,55 @@
+package scraper_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/hypershift-community/ci-testgrid/scraper/fixtures"
+	"github.com/hypershift-community/ci-testgrid/scraper/httpclient"
+	"github.com/hypershift-community/ci-testgrid/scraper/scraper"
+)
+
+func TestScrapeJobs(t *testing.T) {
+	server := httptest.NewServer(fixtures.Handler(filepath.Join("..", "testdata", "e2e")))
+	defer server.Close()
+	serverURL := server.URL
+	ctx := httpclient.NewContext(context.Background(), httpclient.New(httpclient.Config{}))
+	const history = "/job-history/gs/test-platform-results/pr-logs/directory/pull-ci-openshift-hypershift-main-e2e-aws"
+	const suffix = "/artifacts/e2e-aws/hypershift-aws-run-e2e-nested/build-log.txt"
+
+	jobs, older, err := scraper.ScrapeJobs(ctx, serverURL+history, serverURL, suffix)
+	if err != nil {
+		t.Fatalf("ScrapeJobs returned error: %v", err)
+	}
+	var ids []string
+	for _, job := range jobs {
+		ids = append(ids, job.ID)
+	}
+	if want := []string{"1979400000000000004", "1979400000000000003", "1979400000000000002"}; !slices.Equal(ids, want) {
+		t.Errorf("jobs = %v, want %v", ids, want)
+	}
+	if want := serverURL + history + "?buildId=1979400000000000002"; older != want {
+		t.Errorf("older runs URL = %q, want %q", older, want)
+	}
+
+	job := jobs[0]
+	wantLogURL := serverURL + "/gcs/test-platform-results/pr-logs/pull/openshift_hypershift/5021/pull-ci-openshift-hypershift-main-e2e-aws/1979400000000000004" + suffix
+	if job.PR != 5021 || job.Name != "hypershift" || job.Result != "FAILURE" || job.LogURL != wantLogURL {
+		t.Errorf("job = %+v, want PR 5021 of hypershift, failed, with log %s", job, wantLogURL)
+	}
+
+	// The last page has a pending build, which is skipped, and no older runs
+	jobs, older, err = scraper.ScrapeJobs(ctx, serverURL+history+"?buildId=1979400000000000002", serverURL, suffix)
+	if err != nil {
+		t.Fatalf("ScrapeJobs of the older runs returned error: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != "1979400000000000001" {
+		t.Errorf("older jobs = %+v, want 1979400000000000001 only", jobs)
+	}
+	if older != "" {
+		t.Errorf("older runs URL of the last page = %q, want none", older)
+	}
+}
@@ -13,13 +13,46 @@ type Job struct {
 	Tests     []Test `json:"tests" bson:"tests"`
 	JobLink   string `json:"job_link" bson:"job_link"`
 	TestName  string `json:"test_name" bson:"test_name"`
+	SHA       string `json:"sha,omitempty" bson:"sha,omitempty"` // The PR head commit tested
+	// FailureStage and FailureReason explain failed jobs without failed
+	// tests, e.g. when the cluster install failed before any test ran
+	FailureStage  string `json:"failure_stage,omitempty" bson:"failure_stage,omitempty"`
+	FailureReason string `json:"failure_reason,omitempty" bson:"failure_reason,omitempty"`
+	// ArtifactsURL is the directory holding the artifacts of the steps of the
+	// job's test, i.e. artifacts/<test>/
+	ArtifactsURL string `json:"artifacts_url,omitempty" bson:"artifacts_url,omitempty"`
+	// Steps are the ci-operator steps of the job's test, in the order they
+	// started
+	Steps []Step `json:"steps,omitempty" bson:"steps,omitempty"`
 }
 
+// Step is a step of a ci-operator multi-stage test, e.g. ipi-install-install
+type Step struct {
+	Name      string        `json:"name" bson:"name"`
+	Result    string        `json:"result" bson:"result"` // SUCCESS, FAILURE or UNKNOWN if it didn't finish
+	StartedAt string        `json:"started_at" bson:"started_at"`
+	Duration  time.Duration `json:"duration" bson:"duration"`
+}
+
+// Failure stages, from the earliest to the latest
+const (
+	FailureStageLease   = "lease"   // Acquiring the cloud account lease
+	FailureStageImages  = "images"  // Building, importing or pulling images
+	FailureStageInstall = "install" // The pre steps, which install the cluster
+	FailureStageTest    = "test"    // The test steps, outside of any test
+	FailureStagePost    = "post"    // The post steps, e.g. gathering artifacts
+	FailureStageUnknown = "unknown"
+)
+
 type Test struct {
 	Name          string        `json:"name" bson:"name"`
 	Result        string        `json:"result" bson:"result"`
 	Duration      time.Duration `json:"duration" bson:"duration"`
 	Logs          []string      `json:"logs" bson:"logs"`
-	HostedCluster string   `json:"hosted_cluster" bson:"hosted_cluster"`
-	NodePools     []string `json:"nodepools" bson:"nodepools"`
+	HostedCluster string        `json:"hosted_cluster" bson:"hosted_cluster"`
+	NodePools     []string      `json:"nodepools" bson:"nodepools"`
+	// Signature identifies the root cause of a failure across jobs. It is
+	// only set on failed tests.
+	Signature     string `json:"signature,omitempty" bson:"signature,omitempty"`
+	SignatureText string `json:"signature_text,omitempty" bson:"signature_text,omitempty"`
 }
@@ -2,29 +2,57 @@ package main
 
 import (
 	"embed"
-	"fmt"
-	"log"
+	"flag"
+	"log/slog"
 	"net/http"
+	"os"
 
 	"github.com/hypershift-community/ci-testgrid/ui/testgrid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 //go:embed templates/*
 var templateFS embed.FS
 
 func main() {
+	logFormat := flag.String("log-format", "text", "Log format, text or json")
+	logLevel := flag.String("log-level", "info", "Log level: debug, info, warn or error")
+	flag.Parse()
+
+	if err := setupLogging(os.Stderr, *logFormat, *logLevel); err != nil {
+		slog.Error("Error setting up logging", "error", err)
+		os.Exit(1)
+	}
+
 	// Create a new testgrid handler
-	handler, err := testgrid.NewHandler(templateFS)
+	store := testgrid.MongoStore{}
+	handler, err := testgrid.NewHandler(templateFS, store)
+	if err != nil {
+		slog.Error("Error creating testgrid handler", "error", err)
+		os.Exit(1)
+	}
+
+	// Export test pass and failure rates for alerting
+	statsWindows := os.Getenv("STATS_WINDOWS")
+	if statsWindows == "" {
+		statsWindows = testgrid.DefaultStatsWindows
+	}
+	windows, err := testgrid.ParseStatsWindows(statsWindows)
 	if err != nil {
-		log.Fatalf("Error creating testgrid handler: %v", err)
+		slog.Error("Error parsing STATS_WINDOWS", "error", err)
+		os.Exit(1)
 	}
+	prometheus.MustRegister(testgrid.NewStatsCollector(store, windows))
 
 	// Set up routes
 	http.Handle("/", handler)
+	http.Handle("/metrics", promhttp.Handler())
 
 	// Start the server
-	fmt.Println("Starting server on :8080")
+	slog.Info("Starting server", "addr", ":8080")
 	if err := http.ListenAndServe(":8080", nil); err != nil {
-		log.Fatalf("Error starting server: %v", err)
+		slog.Error("Error starting server", "error", err)
+		os.Exit(1)
 	}
 }
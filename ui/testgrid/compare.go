@@ -0,0 +1,279 @@
+package testgrid
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxDurationChanges caps the number of tests listed by duration change
+const maxDurationChanges = 20
+
+// CompareViewModel represents the data for the job comparison view. Job A is
+// the baseline and job B the job compared with it.
+type CompareViewModel struct {
+	JobA, JobB         Job
+	SummaryA, SummaryB TestSummary
+
+	NewlyFailing []TestComparison // Failed in B, but not in A
+	NewlyPassing []TestComparison // Passed in B, but failed in A
+	OtherChanges []TestComparison // Other result changes, e.g. to or from skipped
+	OnlyInA      []TestComparison
+	OnlyInB      []TestComparison
+	Unchanged    int // Number of tests with the same result in both jobs
+
+	// DurationChanges lists the tests that ran in both jobs, by largest
+	// absolute change in duration
+	DurationChanges []TestComparison
+	ManifestDiffs   []ManifestDiff
+}
+
+// TestComparison compares a test's runs in two jobs
+type TestComparison struct {
+	Name      string
+	ResultA   string // Empty if the test didn't run in job A
+	ResultB   string // Empty if the test didn't run in job B
+	DurationA time.Duration
+	DurationB time.Duration
+}
+
+// DurationDelta returns how much longer the test took in job B
+func (c TestComparison) DurationDelta() time.Duration {
+	return c.DurationB - c.DurationA
+}
+
+// ManifestDiff is the difference between a test's captured manifests in two jobs
+type ManifestDiff struct {
+	Test     string
+	Kind     string // HostedCluster or NodePools
+	Lines    []DiffLine
+	TooLarge bool // Whether the manifests were too large to diff
+}
+
+// handleCompare handles the comparison of two jobs
+func (h *Handler) handleCompare(w http.ResponseWriter, r *http.Request, compare string) {
+	ids := strings.Split(compare, ",")
+	if len(ids) != 2 || ids[0] == "" || ids[1] == "" {
+		http.Error(w, "compare must be two job IDs separated by a comma", http.StatusBadRequest)
+		return
+	}
+
+	jobA, err := h.store.Job(ids[0])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching job %s: %v", ids[0], err), http.StatusInternalServerError)
+		return
+	}
+	jobB, err := h.store.Job(ids[1])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching job %s: %v", ids[1], err), http.StatusInternalServerError)
+		return
+	}
+
+	viewModel := compareJobs(*jobA, *jobB)
+
+	err = h.templates.ExecuteTemplate(w, "compare.html", viewModel)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Template execution error", "url", r.URL.String(), "error", err)
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// compareJobs diffs the test results, durations and manifests of two jobs
+func compareJobs(jobA, jobB Job) CompareViewModel {
+	viewModel := CompareViewModel{
+		JobA:     jobA,
+		JobB:     jobB,
+		SummaryA: calculateTestSummary(jobA.Tests),
+		SummaryB: calculateTestSummary(jobB.Tests),
+	}
+
+	testsA := make(map[string]Test)
+	for _, test := range jobA.Tests {
+		testsA[test.Name] = test
+	}
+	testsB := make(map[string]Test)
+	for _, test := range jobB.Tests {
+		testsB[test.Name] = test
+	}
+
+	for _, a := range jobA.Tests {
+		if _, ok := testsB[a.Name]; !ok {
+			viewModel.OnlyInA = append(viewModel.OnlyInA, TestComparison{
+				Name:      a.Name,
+				ResultA:   strings.ToLower(a.Result),
+				DurationA: a.Duration,
+			})
+		}
+	}
+
+	for _, b := range jobB.Tests {
+		a, ok := testsA[b.Name]
+		if !ok {
+			viewModel.OnlyInB = append(viewModel.OnlyInB, TestComparison{
+				Name:      b.Name,
+				ResultB:   strings.ToLower(b.Result),
+				DurationB: b.Duration,
+			})
+			continue
+		}
+
+		comparison := TestComparison{
+			Name:      b.Name,
+			ResultA:   strings.ToLower(a.Result),
+			ResultB:   strings.ToLower(b.Result),
+			DurationA: a.Duration,
+			DurationB: b.Duration,
+		}
+		switch {
+		case comparison.ResultA == comparison.ResultB:
+			viewModel.Unchanged++
+		case comparison.ResultB == "fail":
+			viewModel.NewlyFailing = append(viewModel.NewlyFailing, comparison)
+		case comparison.ResultA == "fail" && comparison.ResultB == "pass":
+			viewModel.NewlyPassing = append(viewModel.NewlyPassing, comparison)
+		default:
+			viewModel.OtherChanges = append(viewModel.OtherChanges, comparison)
+		}
+
+		if a.Duration > 0 && b.Duration > 0 && a.Duration != b.Duration {
+			viewModel.DurationChanges = append(viewModel.DurationChanges, comparison)
+		}
+
+		viewModel.ManifestDiffs = appendManifestDiff(viewModel.ManifestDiffs, b.Name, "HostedCluster", a.HostedCluster, b.HostedCluster)
+		viewModel.ManifestDiffs = appendManifestDiff(viewModel.ManifestDiffs, b.Name, "NodePools", a.NodePools, b.NodePools)
+	}
+
+	for _, list := range [][]TestComparison{viewModel.NewlyFailing, viewModel.NewlyPassing, viewModel.OtherChanges, viewModel.OnlyInA, viewModel.OnlyInB} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	}
+	sort.Slice(viewModel.DurationChanges, func(i, j int) bool {
+		return absDuration(viewModel.DurationChanges[i].DurationDelta()) > absDuration(viewModel.DurationChanges[j].DurationDelta())
+	})
+	if len(viewModel.DurationChanges) > maxDurationChanges {
+		viewModel.DurationChanges = viewModel.DurationChanges[:maxDurationChanges]
+	}
+
+	return viewModel
+}
+
+// appendManifestDiff appends the diff of a test's manifests of a kind, if
+// they were captured in both jobs and differ
+func appendManifestDiff(diffs []ManifestDiff, test, kind string, a, b interface{}) []ManifestDiff {
+	textA, textB := manifestText(a), manifestText(b)
+	if textA == "" || textB == "" || textA == textB {
+		return diffs
+	}
+	lines, ok := diffLines(textA, textB)
+	if ok && lines == nil {
+		return diffs
+	}
+	return append(diffs, ManifestDiff{
+		Test:     test,
+		Kind:     kind,
+		Lines:    lines,
+		TooLarge: !ok,
+	})
+}
+
+// manifestText returns the YAML of a HostedCluster, or of NodePools joined
+// into a multi-document YAML
+func manifestText(manifest interface{}) string {
+	switch m := manifest.(type) {
+	case string:
+		return m
+	case primitive.A:
+		return manifestText([]interface{}(m))
+	case []interface{}:
+		var docs []string
+		for _, doc := range m {
+			if s, ok := doc.(string); ok && s != "" {
+				docs = append(docs, strings.TrimSuffix(s, "\n"))
+			}
+		}
+		return strings.Join(docs, "\n---\n")
+	default:
+		return ""
+	}
+}
+
+// absDuration returns the absolute value of a duration
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// formatDurationDelta formats a duration change with its sign
+func formatDurationDelta(d time.Duration) string {
+	if d > 0 {
+		return "+" + formatDuration(d)
+	}
+	if d < 0 {
+		return "-" + formatDuration(-d)
+	}
+	return formatDuration(d)
+}
+
+// dict builds a map from alternating keys and values, to pass several values
+// to a nested template
+func dict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict needs an even number of arguments")
+	}
+	m := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict keys must be strings, got %T", pairs[i])
+		}
+		m[key] = pairs[i+1]
+	}
+	return m, nil
+}
+
+// fetchPreviousJobID returns the ID of the job of the same test name and PR
+// that started last before the given job, or an empty string if there is none
+func fetchPreviousJobID(job Job) (string, error) {
+	defer observeMongoQuery("previous_job", time.Now())
+
+	// MongoDB connection configuration
+	clientOptions := options.Client().ApplyURI(getMongoDBURI())
+	client, err := mongo.Connect(context.TODO(), clientOptions)
+	if err != nil {
+		return "", err
+	}
+	defer client.Disconnect(context.TODO())
+
+	// Select database and collection
+	collection := client.Database("ci").Collection("jobs")
+
+	filter := bson.M{
+		"test_name":  job.TestName,
+		"pr":         job.PR,
+		"started_at": bson.M{"$lt": job.StartedAt},
+	}
+	findOptions := options.FindOne().
+		SetProjection(bson.M{"_id": 1}).
+		SetSort(bson.D{{Key: "started_at", Value: -1}})
+
+	var previous Job
+	err = collection.FindOne(context.TODO(), filter, findOptions).Decode(&previous)
+	if err == mongo.ErrNoDocuments {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return previous.ID, nil
+}
@@ -0,0 +1,169 @@
+package testgrid
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// comparisonNames returns the names of the compared tests
+func comparisonNames(comparisons []TestComparison) []string {
+	var names []string
+	for _, c := range comparisons {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func TestCompareJobs(t *testing.T) {
+	tests := []struct {
+		name             string
+		testsA, testsB   []Test
+		wantNewlyFailing []string
+		wantNewlyPassing []string
+		wantOther        []string
+		wantOnlyInA      []string
+		wantOnlyInB      []string
+		wantUnchanged    int
+	}{
+		{
+			name: "no tests",
+		},
+		{
+			name:          "identical",
+			testsA:        []Test{{Name: "TestA", Result: "pass"}, {Name: "TestB", Result: "fail"}},
+			testsB:        []Test{{Name: "TestA", Result: "pass"}, {Name: "TestB", Result: "fail"}},
+			wantUnchanged: 2,
+		},
+		{
+			// Results are compared ignoring case
+			name:             "result changes",
+			testsA:           []Test{{Name: "TestD", Result: "pass"}, {Name: "TestC", Result: "FAIL"}, {Name: "TestB", Result: "skip"}, {Name: "TestA", Result: "pass"}, {Name: "TestE", Result: "Pass"}},
+			testsB:           []Test{{Name: "TestD", Result: "fail"}, {Name: "TestC", Result: "pass"}, {Name: "TestB", Result: "fail"}, {Name: "TestA", Result: "skip"}, {Name: "TestE", Result: "pass"}},
+			wantNewlyFailing: []string{"TestB", "TestD"},
+			wantNewlyPassing: []string{"TestC"},
+			wantOther:        []string{"TestA"},
+			wantUnchanged:    1,
+		},
+		{
+			name:          "tests only in A",
+			testsA:        []Test{{Name: "TestB", Result: "pass"}, {Name: "TestA", Result: "fail"}, {Name: "TestC", Result: "pass"}},
+			testsB:        []Test{{Name: "TestC", Result: "pass"}},
+			wantOnlyInA:   []string{"TestA", "TestB"},
+			wantUnchanged: 1,
+		},
+		{
+			name:          "tests only in B",
+			testsA:        []Test{{Name: "TestC", Result: "pass"}},
+			testsB:        []Test{{Name: "TestB", Result: "fail"}, {Name: "TestC", Result: "pass"}, {Name: "TestA", Result: "pass"}},
+			wantOnlyInB:   []string{"TestA", "TestB"},
+			wantUnchanged: 1,
+		},
+		{
+			name:        "job A without tests",
+			testsB:      []Test{{Name: "TestA", Result: "fail"}},
+			wantOnlyInB: []string{"TestA"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := compareJobs(Job{ID: "a", Tests: tt.testsA}, Job{ID: "b", Tests: tt.testsB})
+
+			for _, list := range []struct {
+				name      string
+				got, want []string
+			}{
+				{"newly failing", comparisonNames(got.NewlyFailing), tt.wantNewlyFailing},
+				{"newly passing", comparisonNames(got.NewlyPassing), tt.wantNewlyPassing},
+				{"other changes", comparisonNames(got.OtherChanges), tt.wantOther},
+				{"only in A", comparisonNames(got.OnlyInA), tt.wantOnlyInA},
+				{"only in B", comparisonNames(got.OnlyInB), tt.wantOnlyInB},
+			} {
+				if !slices.Equal(list.got, list.want) {
+					t.Errorf("%s = %q, want %q", list.name, list.got, list.want)
+				}
+			}
+			if got.Unchanged != tt.wantUnchanged {
+				t.Errorf("unchanged = %d, want %d", got.Unchanged, tt.wantUnchanged)
+			}
+			if got.SummaryA.Total != len(tt.testsA) || got.SummaryB.Total != len(tt.testsB) {
+				t.Errorf("summaries count %d and %d tests, want %d and %d", got.SummaryA.Total, got.SummaryB.Total, len(tt.testsA), len(tt.testsB))
+			}
+		})
+	}
+}
+
+func TestCompareJobsDurations(t *testing.T) {
+	var testsA, testsB []Test
+	for i := range maxDurationChanges + 5 {
+		name := fmt.Sprintf("Test%02d", i)
+		testsA = append(testsA, Test{Name: name, Result: "pass", Duration: time.Minute})
+		testsB = append(testsB, Test{Name: name, Result: "pass", Duration: time.Minute + time.Duration(i)*time.Second})
+	}
+	// Tests without a duration in either job, or that took as long, are left
+	// out, and faster tests are ordered by how much faster they were
+	testsA = append(testsA,
+		Test{Name: "TestFaster", Result: "pass", Duration: 10 * time.Minute},
+		Test{Name: "TestNoDuration", Result: "pass"},
+	)
+	testsB = append(testsB,
+		Test{Name: "TestFaster", Result: "pass", Duration: time.Minute},
+		Test{Name: "TestNoDuration", Result: "pass", Duration: time.Minute},
+	)
+
+	got := compareJobs(Job{Tests: testsA}, Job{Tests: testsB})
+	if len(got.DurationChanges) != maxDurationChanges {
+		t.Fatalf("got %d duration changes, want %d", len(got.DurationChanges), maxDurationChanges)
+	}
+	first := got.DurationChanges[0]
+	if first.Name != "TestFaster" || first.DurationDelta() != -9*time.Minute {
+		t.Errorf("largest change = %s by %s, want TestFaster by -9m0s", first.Name, first.DurationDelta())
+	}
+	if second := got.DurationChanges[1].Name; second != fmt.Sprintf("Test%02d", maxDurationChanges+4) {
+		t.Errorf("second largest change = %s, want the slowest test", second)
+	}
+	for _, c := range got.DurationChanges {
+		if c.Name == "TestNoDuration" || c.Name == "Test00" {
+			t.Errorf("duration changes include %s", c.Name)
+		}
+	}
+}
+
+func TestCompareJobsManifests(t *testing.T) {
+	nodePools := func(replicas ...int) primitive.A {
+		var docs primitive.A
+		for _, r := range replicas {
+			docs = append(docs, fmt.Sprintf("kind: NodePool\nreplicas: %d\n", r))
+		}
+		return docs
+	}
+	jobA := Job{Tests: []Test{
+		{Name: "TestCreateCluster", Result: "pass", HostedCluster: "kind: HostedCluster\nrelease: 4.17\n", NodePools: nodePools(2)},
+		{Name: "TestNodePool", Result: "pass", HostedCluster: "kind: HostedCluster\n"},
+		{Name: "TestUpgrade", Result: "pass", NodePools: nodePools(1)},
+	}}
+	jobB := Job{Tests: []Test{
+		{Name: "TestCreateCluster", Result: "pass", HostedCluster: "kind: HostedCluster\nrelease: 4.18\n", NodePools: nodePools(2, 3)},
+		// Manifests with the same lines have no diff
+		{Name: "TestNodePool", Result: "pass", HostedCluster: "kind: HostedCluster"},
+		// Manifests captured in one job only have no diff
+		{Name: "TestUpgrade", Result: "pass", HostedCluster: "kind: HostedCluster\n"},
+	}}
+
+	got := compareJobs(jobA, jobB)
+	var diffs []string
+	for _, diff := range got.ManifestDiffs {
+		diffs = append(diffs, diff.Test+" "+diff.Kind+"\n"+formatDiff(diff.Lines))
+	}
+	want := []string{
+		"TestCreateCluster HostedCluster\n kind: HostedCluster\n-release: 4.17\n+release: 4.18",
+		"TestCreateCluster NodePools\n kind: NodePool\n replicas: 2\n+---\n+kind: NodePool\n+replicas: 3",
+	}
+	if !slices.Equal(diffs, want) {
+		t.Errorf("manifest diffs = %q, want %q", diffs, want)
+	}
+}
@@ -0,0 +1,137 @@
+package testgrid
+
+import "strings"
+
+const (
+	// diffContextLines is how many unchanged lines are kept around changes
+	diffContextLines = 3
+	// maxDiffCells caps the size of the table used to diff two texts, so that
+	// huge manifests don't use up the UI's memory
+	maxDiffCells = 4_000_000
+)
+
+// DiffLine is a line of a line-based diff
+type DiffLine struct {
+	Op   string // "+" for added, "-" for removed, " " for unchanged, "…" for skipped unchanged lines
+	Text string
+}
+
+// diffLines returns a line-based diff from a to b, keeping diffContextLines
+// unchanged lines around each change. It returns nil if a and b have the same
+// lines and false if they are too large to diff.
+func diffLines(a, b string) ([]DiffLine, bool) {
+	if a == b {
+		return nil, true
+	}
+	linesA := splitLines(a)
+	linesB := splitLines(b)
+
+	// Trim the common prefix and suffix, which usually make up most of two
+	// versions of a manifest
+	prefix := 0
+	for prefix < len(linesA) && prefix < len(linesB) && linesA[prefix] == linesB[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(linesA)-prefix && suffix < len(linesB)-prefix &&
+		linesA[len(linesA)-1-suffix] == linesB[len(linesB)-1-suffix] {
+		suffix++
+	}
+	midA := linesA[prefix : len(linesA)-suffix]
+	midB := linesB[prefix : len(linesB)-suffix]
+	if len(midA) == 0 && len(midB) == 0 {
+		// Only a trailing newline differs
+		return nil, true
+	}
+	if (len(midA)+1)*(len(midB)+1) > maxDiffCells {
+		return nil, false
+	}
+
+	var all []DiffLine
+	for _, line := range linesA[:prefix] {
+		all = append(all, DiffLine{Op: " ", Text: line})
+	}
+	all = append(all, lcsDiff(midA, midB)...)
+	for _, line := range linesA[len(linesA)-suffix:] {
+		all = append(all, DiffLine{Op: " ", Text: line})
+	}
+
+	return collapseUnchanged(all), true
+}
+
+// splitLines splits text into lines, ignoring a trailing newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// lcsDiff diffs two line slices using their longest common subsequence
+func lcsDiff(a, b []string) []DiffLine {
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff []DiffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, DiffLine{Op: " ", Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Op: "-", Text: a[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: "+", Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, DiffLine{Op: "-", Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, DiffLine{Op: "+", Text: b[j]})
+	}
+	return diff
+}
+
+// collapseUnchanged replaces runs of unchanged lines further than
+// diffContextLines from a change with a single "…" line
+func collapseUnchanged(diff []DiffLine) []DiffLine {
+	keep := make([]bool, len(diff))
+	for i, line := range diff {
+		if line.Op == " " {
+			continue
+		}
+		for j := max(0, i-diffContextLines); j <= min(len(diff)-1, i+diffContextLines); j++ {
+			keep[j] = true
+		}
+	}
+
+	var result []DiffLine
+	skipped := false
+	for i, line := range diff {
+		if keep[i] {
+			result = append(result, line)
+			skipped = false
+		} else if !skipped {
+			result = append(result, DiffLine{Op: "…"})
+			skipped = true
+		}
+	}
+	return result
+}
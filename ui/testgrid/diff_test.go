@@ -0,0 +1,190 @@
+package testgrid
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// formatDiff returns a diff as one line per DiffLine, prefixed with its op
+func formatDiff(diff []DiffLine) string {
+	var lines []string
+	for _, line := range diff {
+		lines = append(lines, line.Op+line.Text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// numberedLines returns lines named line1 to lineN, each followed by a newline
+func numberedLines(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "line%d\n", i)
+	}
+	return b.String()
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "both empty",
+		},
+		{
+			name: "identical",
+			a:    "kind: HostedCluster\nspec: {}\n",
+			b:    "kind: HostedCluster\nspec: {}\n",
+		},
+		{
+			name: "from empty",
+			b:    "a\nb\n",
+			want: "+a\n+b",
+		},
+		{
+			name: "to empty",
+			a:    "a\nb\n",
+			want: "-a\n-b",
+		},
+		{
+			// A trailing newline is not a line of its own
+			name: "trailing newline",
+			a:    "a\nb",
+			b:    "a\nb\n",
+		},
+		{
+			name: "changed line",
+			a:    "kind: NodePool\nreplicas: 2\nplatform: AWS\n",
+			b:    "kind: NodePool\nreplicas: 3\nplatform: AWS\n",
+			want: " kind: NodePool\n-replicas: 2\n+replicas: 3\n platform: AWS",
+		},
+		{
+			name: "added and removed lines",
+			a:    "a\nb\nc\nd\n",
+			b:    "a\nc\nd\ne\n",
+			want: " a\n-b\n c\n d\n+e",
+		},
+		{
+			// Unchanged lines further than diffContextLines from a change
+			// are collapsed
+			name: "context",
+			a:    numberedLines(20),
+			b:    strings.Replace(numberedLines(20), "line10\n", "line10 changed\n", 1),
+			want: "…\n line7\n line8\n line9\n-line10\n+line10 changed\n line11\n line12\n line13\n…",
+		},
+		{
+			name: "changes far apart",
+			a:    numberedLines(20),
+			b:    strings.NewReplacer("line2\n", "line2 changed\n", "line19\n", "line19 changed\n").Replace(numberedLines(20)),
+			want: " line1\n-line2\n+line2 changed\n line3\n line4\n line5\n…\n line16\n line17\n line18\n-line19\n+line19 changed\n line20",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, ok := diffLines(tt.a, tt.b)
+			if !ok {
+				t.Fatal("diffLines reported the texts too large")
+			}
+			if got := formatDiff(diff); got != tt.want {
+				t.Errorf("diffLines =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	for _, texts := range [][2]string{{"a\n", "a\n"}, {"a", "a\n"}} {
+		if diff, _ := diffLines(texts[0], texts[1]); diff != nil {
+			t.Errorf("diffLines(%q, %q) = %v, want nil", texts[0], texts[1], diff)
+		}
+	}
+}
+
+func TestDiffLinesTooLarge(t *testing.T) {
+	// Lines that all differ leave no common prefix or suffix to trim
+	var a, b strings.Builder
+	for i := range 2100 {
+		fmt.Fprintf(&a, "a%d\n", i)
+		fmt.Fprintf(&b, "b%d\n", i)
+	}
+	if diff, ok := diffLines(a.String(), b.String()); ok || diff != nil {
+		t.Errorf("diffLines = %d lines, %v, want too large", len(diff), ok)
+	}
+
+	// A large text with a small change is trimmed to the change
+	large := numberedLines(5000)
+	changed := strings.Replace(large, "line2500\n", "line2500 changed\n", 1)
+	if diff, ok := diffLines(large, changed); !ok || len(diff) != 2*diffContextLines+4 {
+		t.Errorf("diffLines = %d lines, %v, want the change with its context", len(diff), ok)
+	}
+}
+
+func TestLCSDiff(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		want string
+	}{
+		{},
+		{a: []string{"a"}, want: "-a"},
+		{b: []string{"a"}, want: "+a"},
+		{a: []string{"a", "b"}, b: []string{"a", "b"}, want: " a\n b"},
+		{a: []string{"a", "b", "c"}, b: []string{"b", "c", "d"}, want: "-a\n b\n c\n+d"},
+		// Removed lines come before added ones
+		{a: []string{"a", "x", "c"}, b: []string{"a", "y", "c"}, want: " a\n-x\n+y\n c"},
+	}
+
+	for _, tt := range tests {
+		if got := formatDiff(lcsDiff(tt.a, tt.b)); got != tt.want {
+			t.Errorf("lcsDiff(%q, %q) =\n%s\nwant\n%s", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCollapseUnchanged(t *testing.T) {
+	unchanged := func(n int) []DiffLine {
+		var lines []DiffLine
+		for i := range n {
+			lines = append(lines, DiffLine{Op: " ", Text: fmt.Sprint(i)})
+		}
+		return lines
+	}
+
+	tests := []struct {
+		name string
+		diff []DiffLine
+		want []string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "no changes",
+			diff: unchanged(3),
+			want: []string{"…"},
+		},
+		{
+			name: "context within reach",
+			diff: slices.Concat(unchanged(3), []DiffLine{{Op: "+", Text: "x"}}, unchanged(3)),
+			want: []string{" 0", " 1", " 2", "+x", " 0", " 1", " 2"},
+		},
+		{
+			name: "context beyond reach",
+			diff: slices.Concat(unchanged(5), []DiffLine{{Op: "-", Text: "x"}}, unchanged(5)),
+			want: []string{"…", " 2", " 3", " 4", "-x", " 0", " 1", " 2", "…"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, line := range collapseUnchanged(tt.diff) {
+				got = append(got, line.Op+line.Text)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("collapseUnchanged = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
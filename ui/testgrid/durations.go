@@ -0,0 +1,392 @@
+package testgrid
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// defaultRecentRuns is how many of a test's latest passing runs are
+	// compared against its earlier runs when looking for regressions
+	defaultRecentRuns = 5
+	// defaultRegressionThreshold is the ratio of recent to baseline median
+	// duration at which a test is reported as regressed
+	defaultRegressionThreshold = 1.5
+	// minBaselineRuns is the fewest earlier runs a test needs for its
+	// baseline to be trusted
+	minBaselineRuns = 5
+)
+
+// DailyDuration holds duration percentiles for a test's runs on one day
+type DailyDuration struct {
+	Day  time.Time
+	Runs int
+	P50  time.Duration
+	P90  time.Duration
+}
+
+// DurationRegression is a test whose recent runs are significantly slower
+// than its earlier runs in the window
+type DurationRegression struct {
+	Test           string
+	BaselineMedian time.Duration
+	RecentMedian   time.Duration
+	Ratio          float64
+	BaselineRuns   int
+	RecentRuns     int
+}
+
+// DurationRegressionsViewModel represents the data for the duration
+// regressions view
+type DurationRegressionsViewModel struct {
+	FilterTestName string
+	From           string
+	To             string
+	RecentRuns     int
+	Threshold      float64
+	Regressions    []DurationRegression
+}
+
+// handleDurationRegressions handles the list of tests whose duration regressed
+func (h *Handler) handleDurationRegressions(w http.ResponseWriter, r *http.Request, testName string) {
+	window, err := parseTimeWindow(r.URL.Query(), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	recentRuns := defaultRecentRuns
+	if runsStr := r.URL.Query().Get("runs"); runsStr != "" {
+		recentRuns, err = strconv.Atoi(runsStr)
+		if err != nil || recentRuns < 1 {
+			http.Error(w, fmt.Sprintf("invalid runs parameter: %q", runsStr), http.StatusBadRequest)
+			return
+		}
+	}
+
+	threshold := defaultRegressionThreshold
+	if thresholdStr := r.URL.Query().Get("threshold"); thresholdStr != "" {
+		threshold, err = strconv.ParseFloat(thresholdStr, 64)
+		if err != nil || threshold <= 1 {
+			http.Error(w, fmt.Sprintf("invalid threshold parameter: %q", thresholdStr), http.StatusBadRequest)
+			return
+		}
+	}
+
+	jobs, err := h.store.WindowJobs(testName, window)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching jobs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	viewModel := DurationRegressionsViewModel{
+		FilterTestName: testName,
+		From:           window.From.Format(dateLayout),
+		To:             r.URL.Query().Get("to"),
+		RecentRuns:     recentRuns,
+		Threshold:      threshold,
+		Regressions:    detectDurationRegressions(jobs, recentRuns, threshold),
+	}
+
+	err = h.templates.ExecuteTemplate(w, "durationregressions.html", viewModel)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Template execution error", "url", r.URL.String(), "error", err)
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// fetchWindowJobsFromMongoDB retrieves every job of a test name in the time
+// window, without logs or artifacts
+func fetchWindowJobsFromMongoDB(testName string, window TimeWindow) ([]Job, error) {
+	defer observeMongoQuery("window_jobs", time.Now())
+
+	// MongoDB connection configuration
+	clientOptions := options.Client().ApplyURI(getMongoDBURI())
+	client, err := mongo.Connect(context.TODO(), clientOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Disconnect(context.TODO())
+
+	// Select database and collection
+	collection := client.Database("ci").Collection("jobs")
+
+	filter := bson.M{
+		"started_at": window.mongoFilter(),
+		"test_name":  testName,
+	}
+
+	cursor, err := collection.Find(context.TODO(), filter, options.Find().SetProjection(gridProjection))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var jobs []Job
+	if err = cursor.All(context.TODO(), &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// passedDurations returns the durations of each test's passing runs, ordered
+// from the oldest job to the newest. Failed runs are left out since they
+// often end early or time out.
+func passedDurations(jobs []Job) map[string][]time.Duration {
+	sorted := make([]Job, len(jobs))
+	copy(sorted, jobs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].StartedAt < sorted[j].StartedAt
+	})
+
+	durations := make(map[string][]time.Duration)
+	for _, job := range sorted {
+		for _, test := range job.Tests {
+			if strings.ToLower(test.Result) == "pass" && test.Duration > 0 {
+				durations[test.Name] = append(durations[test.Name], test.Duration)
+			}
+		}
+	}
+	return durations
+}
+
+// durationBaselines returns the median passing duration of each test
+func durationBaselines(jobs []Job) map[string]time.Duration {
+	baselines := make(map[string]time.Duration)
+	for name, durations := range passedDurations(jobs) {
+		baselines[name] = median(durations)
+	}
+	return baselines
+}
+
+// detectDurationRegressions finds tests whose median duration over their last
+// recentRuns passing runs is at least threshold times the median of their
+// earlier passing runs
+func detectDurationRegressions(jobs []Job, recentRuns int, threshold float64) []DurationRegression {
+	var regressions []DurationRegression
+	for name, durations := range passedDurations(jobs) {
+		if len(durations) < recentRuns+minBaselineRuns {
+			continue
+		}
+		split := len(durations) - recentRuns
+		baseline := median(durations[:split])
+		recent := median(durations[split:])
+		if baseline == 0 {
+			continue
+		}
+		ratio := float64(recent) / float64(baseline)
+		if ratio < threshold {
+			continue
+		}
+		regressions = append(regressions, DurationRegression{
+			Test:           name,
+			BaselineMedian: baseline,
+			RecentMedian:   recent,
+			Ratio:          ratio,
+			BaselineRuns:   split,
+			RecentRuns:     recentRuns,
+		})
+	}
+
+	sort.Slice(regressions, func(i, j int) bool {
+		if regressions[i].Ratio != regressions[j].Ratio {
+			return regressions[i].Ratio > regressions[j].Ratio
+		}
+		return regressions[i].Test < regressions[j].Test
+	})
+	return regressions
+}
+
+// median returns the median of unsorted durations
+func median(durations []time.Duration) time.Duration {
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return percentile(sorted, 50)
+}
+
+// dailyDurationPercentiles groups runs by the UTC day their job started and
+// returns p50 and p90 durations of each day's passing and failing runs
+func dailyDurationPercentiles(runs []TestRun) []DailyDuration {
+	byDay := make(map[time.Time][]time.Duration)
+	for _, run := range runs {
+		result := strings.ToLower(run.Test.Result)
+		if run.Test.Duration <= 0 || (result != "pass" && result != "fail") {
+			continue
+		}
+		started, err := time.Parse(time.RFC3339, run.Job.StartedAt)
+		if err != nil {
+			continue
+		}
+		day := started.UTC().Truncate(24 * time.Hour)
+		byDay[day] = append(byDay[day], run.Test.Duration)
+	}
+
+	var days []DailyDuration
+	for day, durations := range byDay {
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		days = append(days, DailyDuration{
+			Day:  day,
+			Runs: len(durations),
+			P50:  percentile(durations, 50),
+			P90:  percentile(durations, 90),
+		})
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Day.Before(days[j].Day) })
+	return days
+}
+
+// durationClass returns the CSS class for a test duration relative to the
+// test's baseline
+func durationClass(d, baseline time.Duration) string {
+	if d <= 0 || baseline <= 0 {
+		return "duration-unknown"
+	}
+	ratio := float64(d) / float64(baseline)
+	switch {
+	case ratio < 0.8:
+		return "duration-faster"
+	case ratio < 1.2:
+		return "duration-normal"
+	case ratio < 1.5:
+		return "duration-slower"
+	default:
+		return "duration-much-slower"
+	}
+}
+
+// formatShortDuration formats a duration compactly for grid cells
+func formatShortDuration(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "-"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%.1fh", d.Hours())
+	}
+}
+
+// Dimensions of the duration chart on the test history page
+const (
+	chartWidth   = 900
+	chartHeight  = 220
+	chartPadLeft = 50
+	chartPadTop  = 10
+	chartPadBot  = 30
+	chartPadR    = 10
+)
+
+// durationChartSVG draws each run's duration as a point colored by result,
+// with lines for the daily p50 and p90
+func durationChartSVG(runs []TestRun, daily []DailyDuration) template.HTML {
+	type point struct {
+		at       time.Time
+		duration time.Duration
+		result   string
+	}
+
+	var points []point
+	var maxDuration time.Duration
+	var minTime, maxTime time.Time
+	for _, run := range runs {
+		if run.Test.Duration <= 0 {
+			continue
+		}
+		started, err := time.Parse(time.RFC3339, run.Job.StartedAt)
+		if err != nil {
+			continue
+		}
+		points = append(points, point{at: started, duration: run.Test.Duration, result: strings.ToLower(run.Test.Result)})
+		if run.Test.Duration > maxDuration {
+			maxDuration = run.Test.Duration
+		}
+		if minTime.IsZero() || started.Before(minTime) {
+			minTime = started
+		}
+		if started.After(maxTime) {
+			maxTime = started
+		}
+	}
+	if len(points) == 0 {
+		return ""
+	}
+
+	plotWidth := float64(chartWidth - chartPadLeft - chartPadR)
+	plotHeight := float64(chartHeight - chartPadTop - chartPadBot)
+	span := maxTime.Sub(minTime)
+	x := func(t time.Time) float64 {
+		if span == 0 {
+			return chartPadLeft + plotWidth/2
+		}
+		return chartPadLeft + plotWidth*float64(t.Sub(minTime))/float64(span)
+	}
+	y := func(d time.Duration) float64 {
+		return chartPadTop + plotHeight*(1-float64(d)/float64(maxDuration))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="duration-chart" viewBox="0 0 %d %d" width="100%%" preserveAspectRatio="xMidYMid meet">`, chartWidth, chartHeight)
+
+	// Axes and labels
+	fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#999"/>`, chartPadLeft, chartPadTop, chartPadLeft, chartHeight-chartPadBot)
+	fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#999"/>`, chartPadLeft, chartHeight-chartPadBot, chartWidth-chartPadR, chartHeight-chartPadBot)
+	fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="11" text-anchor="end">%s</text>`, chartPadLeft-4, chartPadTop+10, html.EscapeString(formatDuration(maxDuration)))
+	fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="11" text-anchor="end">0</text>`, chartPadLeft-4, chartHeight-chartPadBot)
+	fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="11">%s</text>`, chartPadLeft, chartHeight-8, html.EscapeString(minTime.Format("01-02 15:04")))
+	fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="11" text-anchor="end">%s</text>`, chartWidth-chartPadR, chartHeight-8, html.EscapeString(maxTime.Format("01-02 15:04")))
+
+	// Daily percentile lines, plotted at noon of each day
+	for _, line := range []struct {
+		color string
+		value func(DailyDuration) time.Duration
+	}{
+		{"#1976d2", func(d DailyDuration) time.Duration { return d.P50 }},
+		{"#7b1fa2", func(d DailyDuration) time.Duration { return d.P90 }},
+	} {
+		var coords []string
+		for _, day := range daily {
+			noon := day.Day.Add(12 * time.Hour)
+			if noon.Before(minTime) {
+				noon = minTime
+			}
+			if noon.After(maxTime) {
+				noon = maxTime
+			}
+			coords = append(coords, fmt.Sprintf("%.1f,%.1f", x(noon), y(line.value(day))))
+		}
+		if len(coords) > 0 {
+			fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2" stroke-dasharray="4 2"/>`, strings.Join(coords, " "), line.color)
+		}
+	}
+
+	// Individual runs
+	colors := map[string]string{"pass": "green", "fail": "red", "skip": "orange"}
+	for _, p := range points {
+		color, ok := colors[p.result]
+		if !ok {
+			color = "gray"
+		}
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s: %s</title></circle>`,
+			x(p.at), y(p.duration), color, html.EscapeString(p.at.Format("01-02 15:04")), html.EscapeString(formatDuration(p.duration)))
+	}
+
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
@@ -0,0 +1,178 @@
+package testgrid
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// durationJobs returns one job per duration, oldest first, each running
+// test once with that duration and result.
+func durationJobs(test, result string, durations ...time.Duration) []Job {
+	var jobs []Job
+	for i, d := range durations {
+		jobs = append(jobs, Job{
+			ID:        fmt.Sprintf("%s-%d", test, i),
+			StartedAt: time.Date(2026, 10, 1, i, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			Tests:     []Test{{Name: test, Result: result, Duration: d}},
+		})
+	}
+	return jobs
+}
+
+// minutes returns each count of minutes as a duration.
+func minutes(counts ...int) []time.Duration {
+	var durations []time.Duration
+	for _, n := range counts {
+		durations = append(durations, time.Duration(n)*time.Minute)
+	}
+	return durations
+}
+
+func TestDetectDurationRegressions(t *testing.T) {
+	tests := []struct {
+		name      string
+		jobs      []Job
+		threshold float64
+		want      []DurationRegression
+	}{
+		{
+			name:      "regressed",
+			jobs:      durationJobs("TestA", "pass", minutes(10, 10, 10, 10, 10, 20, 20, 20, 20, 20)...),
+			threshold: 1.5,
+			want: []DurationRegression{
+				{Test: "TestA", BaselineMedian: 10 * time.Minute, RecentMedian: 20 * time.Minute, Ratio: 2, BaselineRuns: 5, RecentRuns: 5},
+			},
+		},
+		{
+			name:      "ratio equal to the threshold",
+			jobs:      durationJobs("TestA", "pass", minutes(10, 10, 10, 10, 10, 15, 15, 15, 15, 15)...),
+			threshold: 1.5,
+			want: []DurationRegression{
+				{Test: "TestA", BaselineMedian: 10 * time.Minute, RecentMedian: 15 * time.Minute, Ratio: 1.5, BaselineRuns: 5, RecentRuns: 5},
+			},
+		},
+		{
+			name:      "ratio below the threshold",
+			jobs:      durationJobs("TestA", "pass", minutes(10, 10, 10, 10, 10, 14, 14, 14, 14, 14)...),
+			threshold: 1.5,
+		},
+		{
+			name:      "faster",
+			jobs:      durationJobs("TestA", "pass", minutes(20, 20, 20, 20, 20, 10, 10, 10, 10, 10)...),
+			threshold: 1.5,
+		},
+		{
+			name:      "too few baseline runs",
+			jobs:      durationJobs("TestA", "pass", minutes(10, 10, 10, 10, 20, 20, 20, 20, 20)...),
+			threshold: 1.5,
+		},
+		{
+			// Failed runs often end early, so they are neither baseline
+			// nor recent runs
+			name: "failed runs left out",
+			jobs: append(
+				durationJobs("TestA", "pass", minutes(10, 10, 10, 10, 10, 20, 20, 20, 20, 20)...),
+				durationJobs("TestA", "fail", minutes(1, 1, 1, 1, 1)...)...),
+			threshold: 1.5,
+			want: []DurationRegression{
+				{Test: "TestA", BaselineMedian: 10 * time.Minute, RecentMedian: 20 * time.Minute, Ratio: 2, BaselineRuns: 5, RecentRuns: 5},
+			},
+		},
+		{
+			name:      "one slow recent run",
+			jobs:      durationJobs("TestA", "pass", minutes(10, 10, 10, 10, 10, 10, 10, 60, 10, 10)...),
+			threshold: 1.5,
+		},
+		{
+			name: "ordered by ratio",
+			jobs: append(
+				durationJobs("TestA", "pass", minutes(10, 10, 10, 10, 10, 20, 20, 20, 20, 20)...),
+				durationJobs("TestB", "pass", minutes(10, 10, 10, 10, 10, 30, 30, 30, 30, 30)...)...),
+			threshold: 1.5,
+			want: []DurationRegression{
+				{Test: "TestB", BaselineMedian: 10 * time.Minute, RecentMedian: 30 * time.Minute, Ratio: 3, BaselineRuns: 5, RecentRuns: 5},
+				{Test: "TestA", BaselineMedian: 10 * time.Minute, RecentMedian: 20 * time.Minute, Ratio: 2, BaselineRuns: 5, RecentRuns: 5},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectDurationRegressions(tt.jobs, defaultRecentRuns, tt.threshold)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("detectDurationRegressions = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGridDurationCells(t *testing.T) {
+	h, err := NewHandler(os.DirFS(".."), &memoryStore{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := []Job{
+		{ID: "2", Tests: []Test{{Name: "TestNodePool", Result: "pass", Duration: 30 * time.Minute}}},
+		{ID: "1", Tests: []Test{{Name: "TestNodePool", Result: "fail", Duration: 10 * time.Minute}, {Name: "TestUpgrade", Result: "pass", Duration: 5 * time.Minute}}},
+	}
+	viewModel := TestGridViewModel{
+		Jobs:         jobs,
+		TestGroups:   extractTestGroups(jobs),
+		DurationMode: true,
+		Baselines:    map[string]time.Duration{"TestNodePool": 10 * time.Minute, "TestUpgrade": 5 * time.Minute},
+	}
+	var buf strings.Builder
+	if err := h.templates.ExecuteTemplate(&buf, "testgrid.html", viewModel); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+
+	// Cells are colored against the baselines
+	for _, want := range []string{`class="duration-much-slower"`, `class="duration-normal"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("grid has no %s cell", want)
+		}
+	}
+	// TestUpgrade didn't run in job 2, so its cell is empty
+	if !strings.Contains(page, `<td class="duration-none"></td>`) {
+		t.Error("grid has no empty cell for the test that didn't run")
+	}
+	if strings.Contains(page, "?job=2&test=TestUpgrade") {
+		t.Error("grid links to the logs of a test that didn't run")
+	}
+	if !strings.Contains(page, "?job=1&test=TestUpgrade") {
+		t.Error("grid doesn't link to the logs of a test that ran")
+	}
+}
+
+func TestGridDurationBaselines(t *testing.T) {
+	// TestNodePool took 10 minutes until the latest job, which took 30
+	store := &memoryStore{}
+	for i, duration := range []time.Duration{30, 10, 10, 10} {
+		store.jobs = append(store.jobs, Job{
+			ID:        fmt.Sprint(4 - i),
+			TestName:  "e2e-aws",
+			StartedAt: fmt.Sprintf("2026-10-18T%02d:00:00Z", 12-i),
+			Tests:     []Test{{Name: "TestNodePool", Result: "pass", Duration: duration * time.Minute}},
+		})
+	}
+	handler, err := NewHandler(os.DirFS(".."), store)
+	if err != nil {
+		t.Fatalf("NewHandler returned error: %v", err)
+	}
+
+	// The baseline covers the window, not just the latest job on the page
+	page := get(t, handler, "/?testName=e2e-aws&mode=duration&pageSize=1&from=2026-10-18&to=2026-10-18")
+	if !strings.Contains(page, `class="duration-much-slower"`) {
+		t.Error("latest job isn't much slower than the baseline of the window")
+	}
+
+	// A window of the latest job only has it as its baseline
+	page = get(t, handler, "/?testName=e2e-aws&mode=duration&from=2026-10-18T12:00:00Z&to=2026-10-18T13:00:00Z")
+	if !strings.Contains(page, `class="duration-normal"`) {
+		t.Error("latest job isn't normal against a baseline of itself")
+	}
+}
@@ -0,0 +1,326 @@
+package testgrid
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrapedJobs is the golden file of the scraper's end-to-end test, which
+// holds the jobs it scraped from recorded Prow pages and artifacts
+var scrapedJobs = filepath.Join("..", "..", "scraper", "testdata", "e2e", "jobs.golden.json")
+
+// memoryStore is a JobStore of jobs held in memory
+type memoryStore struct {
+	jobs []Job
+}
+
+// loadMemoryStore returns a memoryStore of the jobs in a JSON file
+func loadMemoryStore(t *testing.T, path string) *memoryStore {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading jobs: %v", err)
+	}
+	var store memoryStore
+	if err := json.Unmarshal(data, &store.jobs); err != nil {
+		t.Fatalf("decoding jobs: %v", err)
+	}
+	return &store
+}
+
+// GridJobs implements JobStore. Unlike the MongoDB query, it returns the tests'
+// logs and the steps too, which the grid ignores.
+func (s *memoryStore) GridJobs(query GridQuery) ([]Job, bool, error) {
+	var jobs []Job
+	for _, job := range s.jobs {
+		switch {
+		case job.TestName != query.TestName,
+			query.PR > 0 && job.PR != query.PR,
+			query.FailedOnly && job.Result != "FAILURE",
+			!inWindow(job, query.Window):
+			continue
+		}
+		// The handler appends to the tests
+		job.Tests = slices.Clone(job.Tests)
+		jobs = append(jobs, job)
+	}
+	sortNewestFirst(jobs)
+
+	start := min((query.Page-1)*query.PageSize, len(jobs))
+	end := min(start+query.PageSize, len(jobs))
+	return jobs[start:end], end < len(jobs), nil
+}
+
+// Job implements JobStore
+func (s *memoryStore) Job(id string) (*Job, error) {
+	for _, job := range s.jobs {
+		if job.ID == id {
+			job.Tests = slices.Clone(job.Tests)
+			return &job, nil
+		}
+	}
+	return nil, fmt.Errorf("job %s not found", id)
+}
+
+// PreviousJobID implements JobStore
+func (s *memoryStore) PreviousJobID(job Job) (string, error) {
+	var previous Job
+	for _, other := range s.jobs {
+		if other.TestName == job.TestName && other.PR == job.PR &&
+			other.StartedAt < job.StartedAt && other.StartedAt > previous.StartedAt {
+			previous = other
+		}
+	}
+	return previous.ID, nil
+}
+
+// TestHistory implements JobStore. Like the MongoDB $filter, it keeps every
+// run of the test in a job.
+func (s *memoryStore) TestHistory(testName, test string, window TimeWindow) ([]Job, error) {
+	var jobs []Job
+	for _, job := range s.jobs {
+		if job.TestName != testName || !inWindow(job, window) {
+			continue
+		}
+		tests := job.Tests
+		job.Tests = nil
+		for _, t := range tests {
+			if t.Name == test {
+				job.Tests = append(job.Tests, t)
+			}
+		}
+		if job.Tests != nil {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+// WindowJobs implements JobStore
+func (s *memoryStore) WindowJobs(testName string, window TimeWindow) ([]Job, error) {
+	var jobs []Job
+	for _, job := range s.jobs {
+		if job.TestName == testName && inWindow(job, window) {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+// SearchJobs implements JobStore. Like the MongoDB text search, it matches the
+// terms ignoring case anywhere in a job's test logs.
+func (s *memoryStore) SearchJobs(query SearchQuery, visit func(Job) bool) error {
+	var jobs []Job
+	for _, job := range s.jobs {
+		if query.TestName != "" && job.TestName != query.TestName || !inWindow(job, query.Window) {
+			continue
+		}
+		var logs []string
+		hasTest := query.Test == ""
+		for _, test := range job.Tests {
+			logs = append(logs, test.Logs...)
+			if strings.Contains(strings.ToLower(test.Name), strings.ToLower(query.Test)) {
+				hasTest = true
+			}
+		}
+		text := strings.ToLower(strings.Join(logs, "\n"))
+		matches := hasTest
+		for _, term := range query.Terms {
+			matches = matches && strings.Contains(text, strings.ToLower(term))
+		}
+		if matches {
+			jobs = append(jobs, job)
+		}
+	}
+	sortNewestFirst(jobs)
+	for _, job := range jobs {
+		if !visit(job) {
+			break
+		}
+	}
+	return nil
+}
+
+// PRJobs implements JobStore
+func (s *memoryStore) PRJobs(pr int) ([]Job, error) {
+	var jobs []Job
+	for _, job := range s.jobs {
+		if job.PR == pr {
+			jobs = append(jobs, job)
+		}
+	}
+	sortNewestFirst(jobs)
+	slices.Reverse(jobs)
+	return jobs, nil
+}
+
+// LandingJobs implements JobStore
+func (s *memoryStore) LandingJobs(since time.Time) (map[string]string, []Job, error) {
+	lastRuns := make(map[string]string)
+	for _, job := range s.jobs {
+		if job.StartedAt > lastRuns[job.TestName] {
+			lastRuns[job.TestName] = job.StartedAt
+		}
+	}
+	jobs, err := s.StatsJobs(since)
+	return lastRuns, jobs, err
+}
+
+// StatsJobs implements JobStore
+func (s *memoryStore) StatsJobs(since time.Time) ([]Job, error) {
+	var jobs []Job
+	for _, job := range s.jobs {
+		if inWindow(job, TimeWindow{From: since}) {
+			jobs = append(jobs, job)
+		}
+	}
+	sortNewestFirst(jobs)
+	return jobs, nil
+}
+
+// inWindow reports whether job started in the time window
+func inWindow(job Job, window TimeWindow) bool {
+	if job.StartedAt < window.From.UTC().Format(time.RFC3339) {
+		return false
+	}
+	return window.To.IsZero() || job.StartedAt < window.To.UTC().Format(time.RFC3339)
+}
+
+// sortNewestFirst sorts jobs by start time, newest first
+func sortNewestFirst(jobs []Job) {
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt > jobs[j].StartedAt
+	})
+}
+
+// get serves a request for target and returns the page
+func get(t *testing.T, handler http.Handler, target string) string {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET %s returned %d: %s", target, recorder.Code, recorder.Body)
+	}
+	return recorder.Body.String()
+}
+
+var gridRowRe = regexp.MustCompile(`<th title="([^"]*)">`)
+
+// TestRenderScrapedJobs renders the grid and the job details of the jobs the
+// scraper stored in its end-to-end test
+func TestRenderScrapedJobs(t *testing.T) {
+	handler, err := NewHandler(os.DirFS(".."), loadMemoryStore(t, scrapedJobs))
+	if err != nil {
+		t.Fatalf("NewHandler returned error: %v", err)
+	}
+
+	t.Run("grid", func(t *testing.T) {
+		page := get(t, handler, "/?testName=e2e-aws&from=2026-10-17&to=2026-10-18")
+
+		var rows []string
+		for _, match := range gridRowRe.FindAllStringSubmatch(page, -1) {
+			rows = append(rows, match[1])
+		}
+		// Rows failing most recently come first
+		want := []string{"TestCreateCluster", "TestNodePool", "[infrastructure] install", "TestUpgradeControlPlane"}
+		if !slices.Equal(rows, want) {
+			t.Errorf("rows = %q, want %q", rows, want)
+		}
+		for _, id := range []string{"1979400000000000004", "1979400000000000003", "1979400000000000002", "1979400000000000001"} {
+			if !strings.Contains(page, "?job="+id) {
+				t.Errorf("grid has no link to job %s", id)
+			}
+		}
+
+		// The window ends before the jobs of October 18
+		page = get(t, handler, "/?testName=e2e-aws&from=2026-10-17&to=2026-10-17")
+		if strings.Contains(page, "1979400000000000004") || !strings.Contains(page, "1979400000000000002") {
+			t.Error("grid of October 17 doesn't show the jobs of that day only")
+		}
+	})
+
+	t.Run("job details", func(t *testing.T) {
+		page := get(t, handler, "/?job=1979400000000000004")
+		for _, want := range []string{
+			`class="step-bar step-FAILURE"`,
+			"hypershift-aws-run-e2e-nested",
+			"Failed to wait for 2 nodes to become ready in 30m0s",
+			// Job 1979400000000000003 of the same PR ran before
+			"/?compare=1979400000000000003,1979400000000000004",
+		} {
+			if !strings.Contains(page, want) {
+				t.Errorf("job details page doesn't contain %q", want)
+			}
+		}
+		if strings.Contains(page, `class="infra-banner"`) {
+			t.Error("job details page of a job with failed tests has an infrastructure banner")
+		}
+	})
+
+	t.Run("infrastructure failure", func(t *testing.T) {
+		page := get(t, handler, "/?job=1979400000000000003")
+		if !strings.Contains(page, "This job failed during the cluster install.") {
+			t.Error("job details page doesn't explain the install failure")
+		}
+		if !strings.Contains(page, "e2e-aws-ipi-install-install container test") {
+			t.Error("job details page doesn't show the failure reason")
+		}
+	})
+
+	// The other pages are rendered from the same store
+	for _, tt := range []struct {
+		name   string
+		target string
+		want   []string
+	}{
+		{
+			name:   "landing",
+			target: "/",
+			want:   []string{`href="/?testName=e2e-aws"`, "Last run 10-18 12:00"},
+		},
+		{
+			name:   "test history",
+			target: "/?testName=e2e-aws&history=TestCreateCluster&from=2026-10-17&to=2026-10-18",
+			want:   []string{"33.3%", "observed invalid nodes: example-x7k2p is NotReady", "observed invalid nodes: example-q9r4t is NotReady"},
+		},
+		{
+			name:   "failure signatures",
+			target: "/?testName=e2e-aws&signatures&from=2026-10-17&to=2026-10-18",
+			want:   []string{"b843c67a3576afd5", "1 tests in 2 jobs", "d64dc1a0c9335922"},
+		},
+		{
+			name:   "duration regressions",
+			target: "/?testName=e2e-aws&regressions&from=2026-10-17&to=2026-10-18",
+			want:   []string{"No duration regressions found."},
+		},
+		{
+			name:   "search",
+			target: "/?search=nodes+ready&from=2026-10-17&to=2026-10-18",
+			want:   []string{"2 matching failed tests", "?job=1979400000000000004", "?job=1979400000000000001"},
+		},
+		{
+			name:   "PR",
+			target: "/?pr=5030",
+			want:   []string{"2 runs across 1 test names", "?job=1979400000000000002", "Failing Tests (1)"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			page := get(t, handler, tt.target)
+			for _, want := range tt.want {
+				if !strings.Contains(page, want) {
+					t.Errorf("page doesn't contain %q", want)
+				}
+			}
+		})
+	}
+}
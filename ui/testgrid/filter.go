@@ -0,0 +1,108 @@
+package testgrid
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GridFilter narrows down the rows and columns shown in the grid. It is read
+// from the include, exclude, failing, topLevel and failedJobs query
+// parameters so that filtered views can be shared.
+type GridFilter struct {
+	Include        string // Only rows whose test name matches this regexp, if set
+	Exclude        string // No rows whose test name matches this regexp, if set
+	FailingOnly    bool   // Only rows with at least one failure in the shown jobs
+	TopLevelOnly   bool   // Only top-level tests, not subtests
+	FailedJobsOnly bool   // Only columns of failed jobs
+
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// parseGridFilter reads the grid filter query parameters
+func parseGridFilter(query url.Values) (GridFilter, error) {
+	filter := GridFilter{
+		Include: query.Get("include"),
+		Exclude: query.Get("exclude"),
+	}
+
+	var err error
+	if filter.Include != "" {
+		if filter.include, err = regexp.Compile(filter.Include); err != nil {
+			return filter, fmt.Errorf("invalid include parameter: %v", err)
+		}
+	}
+	if filter.Exclude != "" {
+		if filter.exclude, err = regexp.Compile(filter.Exclude); err != nil {
+			return filter, fmt.Errorf("invalid exclude parameter: %v", err)
+		}
+	}
+
+	for name, value := range map[string]*bool{
+		"failing":    &filter.FailingOnly,
+		"topLevel":   &filter.TopLevelOnly,
+		"failedJobs": &filter.FailedJobsOnly,
+	} {
+		s := query.Get(name)
+		if s == "" {
+			continue
+		}
+		if *value, err = strconv.ParseBool(s); err != nil {
+			return filter, fmt.Errorf("invalid %s parameter: %q", name, s)
+		}
+	}
+
+	return filter, nil
+}
+
+// Active reports whether the filter hides any rows or columns
+func (f GridFilter) Active() bool {
+	return f.Include != "" || f.Exclude != "" || f.FailingOnly || f.TopLevelOnly || f.FailedJobsOnly
+}
+
+// filterTestGroups returns the test names that pass the row filters, keeping
+// their order
+func (f GridFilter) filterTestGroups(testGroups []string, jobs []Job) []string {
+	var failing map[string]bool
+	if f.FailingOnly {
+		failing = make(map[string]bool)
+		for _, job := range jobs {
+			for _, test := range job.Tests {
+				if strings.ToLower(test.Result) == "fail" {
+					failing[test.Name] = true
+				}
+			}
+		}
+	}
+
+	var result []string
+	for _, name := range testGroups {
+		if f.include != nil && !f.include.MatchString(name) {
+			continue
+		}
+		if f.exclude != nil && f.exclude.MatchString(name) {
+			continue
+		}
+		if f.TopLevelOnly && strings.Contains(name, "/") {
+			continue
+		}
+		if f.FailingOnly && !failing[name] {
+			continue
+		}
+		result = append(result, name)
+	}
+	return result
+}
+
+// gridFilterParams are the query parameters read by parseGridFilter
+var gridFilterParams = []string{"include", "exclude", "failing", "topLevel", "failedJobs"}
+
+// clearFilterURL returns the current request's query without the grid filter
+// parameters, starting from the first page
+func clearFilterURL(r *http.Request) string {
+	return withQuery(r, "page", "", gridFilterParams...)
+}
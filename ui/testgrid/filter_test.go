@@ -0,0 +1,191 @@
+package testgrid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseGridFilter(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    GridFilter
+		wantErr bool
+	}{
+		{query: ""},
+		{
+			query: "include=^TestNode&exclude=Upgrade$",
+			want:  GridFilter{Include: "^TestNode", Exclude: "Upgrade$"},
+		},
+		{
+			query: "failing=true&topLevel=1&failedJobs=t",
+			want:  GridFilter{FailingOnly: true, TopLevelOnly: true, FailedJobsOnly: true},
+		},
+		{
+			query: "failing=false&topLevel=0",
+			want:  GridFilter{},
+		},
+		{query: "include=Test(Node", wantErr: true},
+		{query: "exclude=[", wantErr: true},
+		{query: "failing=yes", wantErr: true},
+		{query: "topLevel=on", wantErr: true},
+		{query: "failedJobs=2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parseGridFilter(query)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseGridFilter(%q) = %+v, want an error", tt.query, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseGridFilter(%q) returned error: %v", tt.query, err)
+			}
+			got.include, got.exclude = nil, nil
+			if got != tt.want {
+				t.Errorf("parseGridFilter(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+			if active := tt.want != (GridFilter{}); got.Active() != active {
+				t.Errorf("parseGridFilter(%q).Active() = %v, want %v", tt.query, got.Active(), active)
+			}
+		})
+	}
+}
+
+func TestFilterTestGroups(t *testing.T) {
+	testGroups := []string{
+		"TestCreateCluster",
+		"TestNodePool",
+		"TestNodePool/autorepair",
+		"TestNodePool/upgrade",
+		"TestUpgradeControlPlane",
+	}
+	jobs := []Job{
+		{Tests: []Test{{Name: "TestCreateCluster", Result: "pass"}, {Name: "TestNodePool/upgrade", Result: "FAIL"}}},
+		{Tests: []Test{{Name: "TestCreateCluster", Result: "fail"}, {Name: "TestNodePool", Result: "skip"}}},
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{
+			query: "",
+			want:  testGroups,
+		},
+		{
+			query: "include=NodePool",
+			want:  []string{"TestNodePool", "TestNodePool/autorepair", "TestNodePool/upgrade"},
+		},
+		{
+			query: "exclude=(?i)upgrade",
+			want:  []string{"TestCreateCluster", "TestNodePool", "TestNodePool/autorepair"},
+		},
+		{
+			// Rows must match include and not match exclude
+			query: "include=NodePool&exclude=upgrade",
+			want:  []string{"TestNodePool", "TestNodePool/autorepair"},
+		},
+		{
+			query: "topLevel=true",
+			want:  []string{"TestCreateCluster", "TestNodePool", "TestUpgradeControlPlane"},
+		},
+		{
+			// Subtests are hidden even when include matches them
+			query: "include=NodePool&topLevel=true",
+			want:  []string{"TestNodePool"},
+		},
+		{
+			query: "failing=true",
+			want:  []string{"TestCreateCluster", "TestNodePool/upgrade"},
+		},
+		{
+			query: "failing=true&topLevel=true",
+			want:  []string{"TestCreateCluster"},
+		},
+		{
+			query: "include=^TestUpgrade&failing=true",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			filter, err := parseGridFilter(query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := filter.filterTestGroups(testGroups, jobs); !slices.Equal(got, tt.want) {
+				t.Errorf("filterTestGroups = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClearFilterURL(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: "testName=e2e-aws&include=Node&exclude=Upgrade&failing=true&topLevel=1&failedJobs=true&page=2&days=3",
+			want:  "?days=3&testName=e2e-aws",
+		},
+		{
+			query: "testName=e2e-aws&pr=5021&mode=duration",
+			want:  "?mode=duration&pr=5021&testName=e2e-aws",
+		},
+		{
+			query: "",
+			want:  "?",
+		},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/?"+tt.query, nil)
+		if got := clearFilterURL(r); got != tt.want {
+			t.Errorf("clearFilterURL(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestGridFailedJobsOnly(t *testing.T) {
+	handler, err := NewHandler(os.DirFS(".."), loadMemoryStore(t, scrapedJobs))
+	if err != nil {
+		t.Fatalf("NewHandler returned error: %v", err)
+	}
+
+	// Job 1979400000000000002 is the only one that succeeded
+	page := get(t, handler, "/?testName=e2e-aws&from=2026-10-17&to=2026-10-18&failedJobs=true")
+	if strings.Contains(page, "?job=1979400000000000002") {
+		t.Error("grid of failed jobs shows a successful job")
+	}
+	for _, id := range []string{"1979400000000000004", "1979400000000000003", "1979400000000000001"} {
+		if !strings.Contains(page, "?job="+id) {
+			t.Errorf("grid of failed jobs has no link to job %s", id)
+		}
+	}
+
+	// Invalid filters are rejected
+	for _, target := range []string{"/?testName=e2e-aws&include=(", "/?testName=e2e-aws&failedJobs=maybe"} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("GET %s returned %d, want %d", target, recorder.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package testgrid
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// logPreviewLines is how many log lines are shown for each failed run on the
+// test history page.
+const logPreviewLines = 5
+
+// TestHistoryViewModel represents the data for the test history view
+type TestHistoryViewModel struct {
+	FilterTestName string // The test name (job type) being viewed
+	Test           string // The individual test whose history is shown
+	From           string // Start of the time window, as a date
+	To             string // End of the time window as requested, if any
+	Runs           []TestRun
+	Stats          TestHistoryStats
+	Daily          []DailyDuration // Duration percentiles per day, oldest first
+	Chart          template.HTML   // SVG chart of durations over time
+}
+
+// TestRun is a single run of a test within a job
+type TestRun struct {
+	Job        Job
+	Test       Test
+	LogPreview []string
+	MoreLogs   int // Number of log lines not included in LogPreview
+}
+
+// TestHistoryStats contains pass rate and duration statistics for a test
+type TestHistoryStats struct {
+	Total          int
+	Passed         int
+	Failed         int
+	Skipped        int
+	PassRate       float64 // Percentage of passed runs among passed and failed runs
+	MinDuration    time.Duration
+	MeanDuration   time.Duration
+	MedianDuration time.Duration
+	P90Duration    time.Duration
+	MaxDuration    time.Duration
+}
+
+// handleTestHistory handles the history view for a single test
+func (h *Handler) handleTestHistory(w http.ResponseWriter, r *http.Request, testName, test string) {
+	window, err := parseTimeWindow(r.URL.Query(), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jobs, err := h.store.TestHistory(testName, test, window)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching test history: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Sort jobs by StartedAt timestamp, newest first
+	sort.Slice(jobs, func(i, j int) bool {
+		timeI, _ := time.Parse(time.RFC3339, jobs[i].StartedAt)
+		timeJ, _ := time.Parse(time.RFC3339, jobs[j].StartedAt)
+		return timeI.After(timeJ)
+	})
+
+	runs, tests := buildTestRuns(jobs, test)
+
+	daily := dailyDurationPercentiles(runs)
+	viewModel := TestHistoryViewModel{
+		FilterTestName: testName,
+		Test:           test,
+		Runs:           runs,
+		Stats:          calculateTestHistoryStats(tests),
+		Daily:          daily,
+		Chart:          durationChartSVG(runs, daily),
+		From:           window.From.Format(dateLayout),
+		To:             r.URL.Query().Get("to"),
+	}
+
+	err = h.templates.ExecuteTemplate(w, "testhistory.html", viewModel)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Template execution error", "url", r.URL.String(), "error", err)
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// buildTestRuns returns every run of a test in jobs, in order, along with the
+// test of each run. A job can hold several runs of the test, e.g. when it was
+// retried. Failed runs get a preview of their logs.
+func buildTestRuns(jobs []Job, test string) ([]TestRun, []Test) {
+	var runs []TestRun
+	var tests []Test
+	for _, job := range jobs {
+		for _, t := range job.Tests {
+			if t.Name != test {
+				continue
+			}
+			run := TestRun{Job: job, Test: t}
+			if strings.ToLower(t.Result) == "fail" {
+				run.LogPreview = t.Logs
+				if len(t.Logs) > logPreviewLines {
+					run.LogPreview = t.Logs[:logPreviewLines]
+					run.MoreLogs = len(t.Logs) - logPreviewLines
+				}
+			}
+			runs = append(runs, run)
+			tests = append(tests, t)
+		}
+	}
+	return runs, tests
+}
+
+// fetchTestHistoryFromMongoDB retrieves the jobs of a test name (job type) in
+// the time window that ran the given test. Only the matching tests are
+// returned in each job's Tests, all of them if the test ran more than once.
+func fetchTestHistoryFromMongoDB(testName, test string, window TimeWindow) ([]Job, error) {
+	defer observeMongoQuery("test_history", time.Now())
+
+	// MongoDB connection configuration
+	clientOptions := options.Client().ApplyURI(getMongoDBURI())
+	client, err := mongo.Connect(context.TODO(), clientOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Disconnect(context.TODO())
+
+	// Select database and collection
+	collection := client.Database("ci").Collection("jobs")
+
+	filter := bson.M{
+		"started_at": window.mongoFilter(),
+		"test_name":  testName,
+		"tests.name": test,
+	}
+	// Unlike an $elemMatch projection, $filter keeps every matching test
+	projection := bson.M{
+		"name":       1,
+		"result":     1,
+		"started_at": 1,
+		"pr":         1,
+		"job_link":   1,
+		"test_name":  1,
+		"tests": bson.M{"$filter": bson.M{
+			"input": "$tests",
+			"as":    "test",
+			"cond":  bson.M{"$eq": bson.A{"$$test.name", test}},
+		}},
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$project", Value: projection}},
+	}
+
+	cursor, err := collection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var jobs []Job
+	if err = cursor.All(context.TODO(), &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// calculateTestHistoryStats calculates pass rate and duration statistics over
+// runs of a single test. Durations of skipped runs and runs without a recorded
+// duration are ignored.
+func calculateTestHistoryStats(tests []Test) TestHistoryStats {
+	stats := TestHistoryStats{Total: len(tests)}
+
+	var durations []time.Duration
+	var totalDuration time.Duration
+	for _, test := range tests {
+		switch strings.ToLower(test.Result) {
+		case "pass":
+			stats.Passed++
+		case "fail":
+			stats.Failed++
+		case "skip":
+			stats.Skipped++
+			continue
+		}
+		if test.Duration > 0 {
+			durations = append(durations, test.Duration)
+			totalDuration += test.Duration
+		}
+	}
+
+	if stats.Passed+stats.Failed > 0 {
+		stats.PassRate = 100 * float64(stats.Passed) / float64(stats.Passed+stats.Failed)
+	}
+
+	if len(durations) > 0 {
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		stats.MinDuration = durations[0]
+		stats.MaxDuration = durations[len(durations)-1]
+		stats.MeanDuration = totalDuration / time.Duration(len(durations))
+		stats.MedianDuration = percentile(durations, 50)
+		stats.P90Duration = percentile(durations, 90)
+	}
+
+	return stats
+}
+
+// percentile returns the p-th percentile of sorted durations using the
+// nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// formatDuration rounds a duration for display
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,79 @@
+package testgrid
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBuildTestRuns(t *testing.T) {
+	var logs []string
+	for i := range logPreviewLines + 2 {
+		logs = append(logs, fmt.Sprintf("line %d", i))
+	}
+	jobs := []Job{
+		{ID: "3", Tests: []Test{
+			{Name: "TestCreateCluster", Result: "pass"},
+			{Name: "TestNodePool", Result: "fail", Logs: logs},
+		}},
+		{ID: "2", Tests: []Test{{Name: "TestCreateCluster", Result: "fail", Logs: []string{"timed out"}}}},
+		// Retried within the job
+		{ID: "1", Tests: []Test{
+			{Name: "TestCreateCluster", Result: "fail", Logs: []string{"first try"}},
+			{Name: "TestCreateCluster", Result: "pass", Logs: []string{"second try"}},
+		}},
+	}
+
+	runs, tests := buildTestRuns(jobs, "TestCreateCluster")
+
+	var got []string
+	for _, run := range runs {
+		got = append(got, fmt.Sprintf("%s %s %q +%d", run.Job.ID, run.Test.Result, run.LogPreview, run.MoreLogs))
+	}
+	want := []string{
+		`3 pass [] +0`,
+		`2 fail ["timed out"] +0`,
+		`1 fail ["first try"] +0`,
+		`1 pass [] +0`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("runs = %q, want %q", got, want)
+	}
+	if len(tests) != len(runs) {
+		t.Errorf("got %d tests for %d runs", len(tests), len(runs))
+	}
+
+	// The log preview of a failed run is cut to logPreviewLines
+	runs, _ = buildTestRuns(jobs, "TestNodePool")
+	if len(runs) != 1 || !slices.Equal(runs[0].LogPreview, logs[:logPreviewLines]) || runs[0].MoreLogs != 2 {
+		t.Errorf("runs = %+v, want one run previewing %d of %d log lines", runs, logPreviewLines, len(logs))
+	}
+}
+
+func TestCalculateTestHistoryStats(t *testing.T) {
+	tests := []Test{
+		{Result: "pass", Duration: 3 * time.Minute},
+		{Result: "pass", Duration: time.Minute},
+		{Result: "fail", Duration: 2 * time.Minute},
+		{Result: "fail"},
+		{Result: "skip", Duration: time.Hour},
+	}
+
+	got := calculateTestHistoryStats(tests)
+	want := TestHistoryStats{
+		Total:          5,
+		Passed:         2,
+		Failed:         2,
+		Skipped:        1,
+		PassRate:       50,
+		MinDuration:    time.Minute,
+		MeanDuration:   2 * time.Minute,
+		MedianDuration: 2 * time.Minute,
+		P90Duration:    3 * time.Minute,
+		MaxDuration:    3 * time.Minute,
+	}
+	if got != want {
+		t.Errorf("stats = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,46 @@
+package testgrid
+
+import "strings"
+
+// infraRowPrefix starts the names of the grid's synthetic rows, which show
+// the jobs that failed outside of their tests
+const infraRowPrefix = "[infrastructure] "
+
+// failureStageLabels describe the failure stages recorded by the scraper
+var failureStageLabels = map[string]string{
+	"lease":   "while acquiring a cloud account lease",
+	"images":  "while building, importing or pulling images",
+	"install": "during the cluster install",
+	"test":    "in the test step, outside of the tests",
+	"post":    "in the post steps",
+	"unknown": "at an unknown stage",
+}
+
+// FailureStageLabel describes the stage the job failed in
+func (j Job) FailureStageLabel() string {
+	if label, ok := failureStageLabels[j.FailureStage]; ok {
+		return label
+	}
+	return "in the " + j.FailureStage + " stage"
+}
+
+// isInfraRow reports whether a grid row is a synthetic row
+func isInfraRow(testGroup string) bool {
+	return strings.HasPrefix(testGroup, infraRowPrefix)
+}
+
+// addInfraRows adds a failed synthetic test, named after the failure stage
+// and holding the reason, to each job that failed outside of its tests, so
+// these jobs have a row in the grid
+func addInfraRows(jobs []Job) {
+	for i := range jobs {
+		if jobs[i].FailureStage == "" {
+			continue
+		}
+		jobs[i].Tests = append(jobs[i].Tests, Test{
+			Name:        infraRowPrefix + jobs[i].FailureStage,
+			Result:      "fail",
+			InfraReason: jobs[i].FailureReason,
+		})
+	}
+}
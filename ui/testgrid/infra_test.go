@@ -0,0 +1,59 @@
+package testgrid
+
+import (
+	"html"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var gridCellTitleRe = regexp.MustCompile(`<td class="result-[a-z]+" title="([^"]*)">`)
+
+func TestGridInfraRows(t *testing.T) {
+	h, err := NewHandler(os.DirFS(".."), &memoryStore{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := []Job{
+		{ID: "2", Result: "FAILURE", FailureStage: "install", FailureReason: "step e2e-aws-ipi-install-install failed", Tests: []Test{
+			{Name: "TestNodePool", Result: "pass", Logs: []string{"=== RUN   TestNodePool"}},
+		}},
+		{ID: "1", Result: "FAILURE", Tests: []Test{
+			{Name: "TestNodePool", Result: "fail", Logs: []string{"nodes never became ready"}},
+		}},
+	}
+	addInfraRows(jobs)
+
+	if got := len(jobs[0].Tests); got != 2 {
+		t.Fatalf("job 2 has %d tests, want an infrastructure row added", got)
+	}
+	if got := len(jobs[1].Tests); got != 1 {
+		t.Errorf("job 1, which failed in its tests, has %d tests, want 1", got)
+	}
+	row := jobs[0].Tests[1]
+	if row.Name != "[infrastructure] install" || row.Result != "fail" || row.Logs != nil {
+		t.Errorf("infrastructure row = %+v", row)
+	}
+
+	viewModel := TestGridViewModel{Jobs: jobs, TestGroups: extractTestGroups(jobs)}
+	var buf strings.Builder
+	if err := h.templates.ExecuteTemplate(&buf, "testgrid.html", viewModel); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+
+	// Only the infrastructure row's cell explains the failure, not the
+	// cells of tests with logs
+	var titles []string
+	for _, match := range gridCellTitleRe.FindAllStringSubmatch(page, -1) {
+		titles = append(titles, html.UnescapeString(match[1]))
+	}
+	if len(titles) != 1 || titles[0] != "step e2e-aws-ipi-install-install failed" {
+		t.Errorf("cell titles = %q, want the install failure reason only", titles)
+	}
+	// Infrastructure rows have no test history to link to
+	if strings.Contains(strings.ToLower(page), "history=%5binfrastructure") {
+		t.Error("grid links to the history of an infrastructure row")
+	}
+}
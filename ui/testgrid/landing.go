@@ -0,0 +1,215 @@
+package testgrid
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// sparklineJobs is the number of recent jobs shown in a sparkline
+	sparklineJobs = 30
+	// sparklineBarWidth and sparklineHeight size the sparkline SVG
+	sparklineBarWidth = 6
+	sparklineHeight   = 20
+)
+
+// TestNamesViewModel represents the data for the landing page
+type TestNamesViewModel struct {
+	TestNames []TestNameCard
+}
+
+// TestNameCard summarizes the recent jobs of a test name (job type)
+type TestNameCard struct {
+	TestName     string
+	LastRun      string // Start time of the latest job, of any age
+	RunsDay      int    // Finished jobs in the last day
+	PassRateDay  float64
+	RunsWeek     int // Finished jobs in the last week
+	PassRateWeek float64
+	FailingTests int           // Tests whose latest run in the last week failed
+	Sparkline    template.HTML // Results of the latest jobs in the last week
+}
+
+// handleTestNames handles the landing page listing every test name in the
+// database
+func (h *Handler) handleTestNames(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	lastRuns, jobs, err := h.store.LandingJobs(now.AddDate(0, 0, -7))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching test names: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	viewModel := TestNamesViewModel{
+		TestNames: buildTestNameCards(lastRuns, jobs, now),
+	}
+
+	err = h.templates.ExecuteTemplate(w, "testnames.html", viewModel)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Template execution error", "url", r.URL.String(), "error", err)
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// fetchLandingJobsFromMongoDB retrieves the start time of the latest job of
+// every test name, and the jobs started since the given time with only their
+// tests' names and results
+func fetchLandingJobsFromMongoDB(since time.Time) (map[string]string, []Job, error) {
+	defer observeMongoQuery("landing", time.Now())
+
+	// MongoDB connection configuration
+	clientOptions := options.Client().ApplyURI(getMongoDBURI())
+	client, err := mongo.Connect(context.TODO(), clientOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer client.Disconnect(context.TODO())
+
+	// Select database and collection
+	collection := client.Database("ci").Collection("jobs")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$test_name",
+			"last_run": bson.M{"$max": "$started_at"},
+		}}},
+	}
+	cursor, err := collection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, nil, err
+	}
+	var groups []struct {
+		TestName string `bson:"_id"`
+		LastRun  string `bson:"last_run"`
+	}
+	if err = cursor.All(context.TODO(), &groups); err != nil {
+		return nil, nil, err
+	}
+	lastRuns := make(map[string]string)
+	for _, group := range groups {
+		if group.TestName != "" {
+			lastRuns[group.TestName] = group.LastRun
+		}
+	}
+
+	filter := bson.M{
+		"started_at": TimeWindow{From: since}.mongoFilter(),
+	}
+	projection := bson.M{
+		"result":       1,
+		"started_at":   1,
+		"test_name":    1,
+		"tests.name":   1,
+		"tests.result": 1,
+	}
+	findOptions := options.Find().
+		SetProjection(projection).
+		SetSort(bson.D{{Key: "started_at", Value: -1}})
+
+	cursor, err = collection.Find(context.TODO(), filter, findOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var jobs []Job
+	if err = cursor.All(context.TODO(), &jobs); err != nil {
+		return nil, nil, err
+	}
+
+	return lastRuns, jobs, nil
+}
+
+// buildTestNameCards summarizes the jobs, given newest first, of each test
+// name. Test names are sorted alphabetically.
+func buildTestNameCards(lastRuns map[string]string, jobs []Job, now time.Time) []TestNameCard {
+	jobsByTestName := make(map[string][]Job)
+	for _, job := range jobs {
+		jobsByTestName[job.TestName] = append(jobsByTestName[job.TestName], job)
+	}
+
+	dayAgo := now.Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+	var cards []TestNameCard
+	for testName, lastRun := range lastRuns {
+		card := TestNameCard{TestName: testName, LastRun: lastRun}
+		recent := jobsByTestName[testName]
+
+		var passedDay, passedWeek int
+		latestResults := make(map[string]string)
+		for _, job := range recent {
+			passed := strings.ToUpper(job.Result) == "SUCCESS"
+			card.RunsWeek++
+			if passed {
+				passedWeek++
+			}
+			if job.StartedAt >= dayAgo {
+				card.RunsDay++
+				if passed {
+					passedDay++
+				}
+			}
+			for _, test := range job.Tests {
+				if _, seen := latestResults[test.Name]; !seen {
+					latestResults[test.Name] = strings.ToLower(test.Result)
+				}
+			}
+		}
+		if card.RunsDay > 0 {
+			card.PassRateDay = 100 * float64(passedDay) / float64(card.RunsDay)
+		}
+		if card.RunsWeek > 0 {
+			card.PassRateWeek = 100 * float64(passedWeek) / float64(card.RunsWeek)
+		}
+		for _, result := range latestResults {
+			if result == "fail" {
+				card.FailingTests++
+			}
+		}
+		card.Sparkline = sparklineSVG(recent)
+		cards = append(cards, card)
+	}
+
+	sort.Slice(cards, func(i, j int) bool { return cards[i].TestName < cards[j].TestName })
+	return cards
+}
+
+// sparklineSVG draws the results of the latest jobs, given newest first, as a
+// row of bars, oldest on the left. Failed jobs are drawn as full height red
+// bars and passed jobs as half height green bars.
+func sparklineSVG(jobs []Job) template.HTML {
+	if len(jobs) > sparklineJobs {
+		jobs = jobs[:sparklineJobs]
+	}
+	if len(jobs) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	width := len(jobs) * sparklineBarWidth
+	fmt.Fprintf(&b, `<svg class="sparkline" viewBox="0 0 %d %d" width="%d" height="%d">`, width, sparklineHeight, width, sparklineHeight)
+	for i := range jobs {
+		job := jobs[len(jobs)-1-i]
+		color, height := "#9e9e9e", sparklineHeight/2
+		switch strings.ToUpper(job.Result) {
+		case "SUCCESS":
+			color = "#4CAF50"
+		case "FAILURE":
+			color, height = "#f44336", sparklineHeight
+		}
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`,
+			i*sparklineBarWidth, sparklineHeight-height, sparklineBarWidth-1, height, color)
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
@@ -0,0 +1,101 @@
+package testgrid
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildTestNameCards(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	hoursAgo := func(hours int) string {
+		return now.Add(-time.Duration(hours) * time.Hour).Format(time.RFC3339)
+	}
+	lastRuns := map[string]string{
+		"e2e-aws": hoursAgo(1),
+		"e2e-aks": hoursAgo(30),
+		// No jobs in the last week
+		"e2e-kubevirt": "2026-09-01T00:00:00Z",
+	}
+	// Newest first, as fetched
+	jobs := []Job{
+		{TestName: "e2e-aws", Result: "SUCCESS", StartedAt: hoursAgo(1), Tests: []Test{
+			{Name: "TestNodePool", Result: "pass"},
+			{Name: "TestUpgrade", Result: "fail"},
+		}},
+		{TestName: "e2e-aws", Result: "FAILURE", StartedAt: hoursAgo(5), Tests: []Test{
+			{Name: "TestNodePool", Result: "fail"},
+			{Name: "TestCreateCluster", Result: "FAIL"},
+		}},
+		{TestName: "e2e-aws", Result: "success", StartedAt: hoursAgo(48), Tests: []Test{
+			{Name: "TestNodePool", Result: "pass"},
+		}},
+		{TestName: "e2e-aks", Result: "FAILURE", StartedAt: hoursAgo(30), Tests: []Test{
+			{Name: "TestNodePool", Result: "fail"},
+		}},
+	}
+
+	cards := buildTestNameCards(lastRuns, jobs, now)
+
+	var got []string
+	for _, c := range cards {
+		got = append(got, fmt.Sprintf("%s last=%s day=%d/%.0f%% week=%d/%.0f%% failing=%d sparkline=%v",
+			c.TestName, c.LastRun, c.RunsDay, c.PassRateDay, c.RunsWeek, c.PassRateWeek, c.FailingTests, c.Sparkline != ""))
+	}
+	want := []string{
+		fmt.Sprintf("e2e-aks last=%s day=0/0%% week=1/0%% failing=1 sparkline=true", hoursAgo(30)),
+		// TestNodePool passed in its latest run
+		fmt.Sprintf("e2e-aws last=%s day=2/50%% week=3/67%% failing=2 sparkline=true", hoursAgo(1)),
+		"e2e-kubevirt last=2026-09-01T00:00:00Z day=0/0% week=0/0% failing=0 sparkline=false",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("cards =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSparklineSVG(t *testing.T) {
+	if got := sparklineSVG(nil); got != "" {
+		t.Errorf("sparkline of no jobs = %q, want empty", got)
+	}
+
+	// Newest first, drawn oldest to newest
+	jobs := []Job{{Result: "FAILURE"}, {Result: "SUCCESS"}, {Result: "ABORTED"}}
+	svg := string(sparklineSVG(jobs))
+	gray := strings.Index(svg, `fill="#9e9e9e"`)
+	green := strings.Index(svg, `fill="#4CAF50"`)
+	red := strings.Index(svg, `fill="#f44336"`)
+	if gray < 0 || !(gray < green && green < red) {
+		t.Errorf("sparkline bars are not drawn oldest first: %s", svg)
+	}
+
+	// Only the latest sparklineJobs jobs are drawn
+	many := make([]Job, sparklineJobs+10)
+	if bars := strings.Count(string(sparklineSVG(many)), "<rect"); bars != sparklineJobs {
+		t.Errorf("sparkline has %d bars, want %d", bars, sparklineJobs)
+	}
+}
+
+func TestTestNamesTemplate(t *testing.T) {
+	h, err := NewHandler(os.DirFS(".."), &memoryStore{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	lastRuns := map[string]string{"e2e-aws": now.Format(time.RFC3339), "e2e-aks": now.Format(time.RFC3339)}
+	jobs := []Job{{TestName: "e2e-aws", Result: "SUCCESS", StartedAt: now.Format(time.RFC3339)}}
+	viewModel := TestNamesViewModel{TestNames: buildTestNameCards(lastRuns, jobs, now)}
+
+	var buf strings.Builder
+	if err := h.templates.ExecuteTemplate(&buf, "testnames.html", viewModel); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	for _, want := range []string{"?testName=e2e-aws", "?testName=e2e-aks", `class="sparkline"`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("landing page doesn't contain %q", want)
+		}
+	}
+}
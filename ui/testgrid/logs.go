@@ -0,0 +1,250 @@
+package testgrid
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// logPageLines is how many lines of a test's logs are rendered at once;
+	// the job details page loads the rest on demand
+	logPageLines = 500
+	// maxLogPageLines caps the limit parameter of the logs endpoint
+	maxLogPageLines = 5000
+)
+
+// FailedTest is a failed test with the first page of its rendered logs
+type FailedTest struct {
+	Test
+	Anchor    string    // Prefix of the IDs of the test's log lines
+	Lines     []LogLine // The first page of log lines
+	MoreLines int       // Number of log lines not rendered yet
+}
+
+// LogLine is a rendered line of a test's logs
+type LogLine struct {
+	Number int           `json:"number"` // 1-based line number
+	HTML   template.HTML `json:"html"`   // The line with ANSI colors converted to spans
+	Error  bool          `json:"error"`  // Whether the line looks like an error
+}
+
+// LogPage is the JSON response of the logs endpoint
+type LogPage struct {
+	Test   string    `json:"test"`
+	Total  int       `json:"total"`  // Number of lines in the test's logs
+	Offset int       `json:"offset"` // 0-based index of the first line returned
+	Lines  []LogLine `json:"lines"`
+}
+
+// errorLineRe matches log lines that are highlighted as errors
+var errorLineRe = regexp.MustCompile(`(?i)\b(error|fail|failed|failure|panic|fatal)\b|--- FAIL`)
+
+// ansiRe matches ANSI CSI escape sequences
+var ansiRe = regexp.MustCompile(`\x1b\[([0-9;?]*)([A-Za-z])`)
+
+// newFailedTest renders the first page of a failed test's logs. index is the
+// test's position among the job's failed tests, used for its line anchors.
+func newFailedTest(test Test, index int) FailedTest {
+	failed := FailedTest{
+		Test:   test,
+		Anchor: fmt.Sprintf("f%d", index),
+	}
+	end := len(test.Logs)
+	if end > logPageLines {
+		end = logPageLines
+	}
+	failed.Lines = renderLogLines(test.Logs, 0, end)
+	failed.MoreLines = len(test.Logs) - end
+	return failed
+}
+
+// handleTestLogs returns a page of a test's rendered logs as JSON
+func (h *Handler) handleTestLogs(w http.ResponseWriter, r *http.Request, jobID string) {
+	testName := r.URL.Query().Get("test")
+	offset, err := parseNonNegative(r.URL.Query().Get("offset"), 0)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid offset parameter: %v", err), http.StatusBadRequest)
+		return
+	}
+	limit, err := parseNonNegative(r.URL.Query().Get("limit"), logPageLines)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid limit parameter: %v", err), http.StatusBadRequest)
+		return
+	}
+	if limit > maxLogPageLines {
+		limit = maxLogPageLines
+	}
+
+	job, err := h.store.Job(jobID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching job: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	for _, test := range job.Tests {
+		if test.Name != testName {
+			continue
+		}
+		start := min(offset, len(test.Logs))
+		end := min(start+limit, len(test.Logs))
+		page := LogPage{
+			Test:   test.Name,
+			Total:  len(test.Logs),
+			Offset: start,
+			Lines:  renderLogLines(test.Logs, start, end),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			slog.ErrorContext(r.Context(), "Error encoding logs", "build_id", jobID, "error", err)
+		}
+		return
+	}
+
+	http.Error(w, fmt.Sprintf("test %q not found in job %s", testName, jobID), http.StatusNotFound)
+}
+
+// parseNonNegative parses an optional non-negative integer query parameter
+func parseNonNegative(s string, defaultValue int) (int, error) {
+	if s == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%d is negative", n)
+	}
+	return n, nil
+}
+
+// renderLogLines renders logs[start:end]
+func renderLogLines(logs []string, start, end int) []LogLine {
+	var lines []LogLine
+	for i := start; i < end; i++ {
+		rendered, plain := ansiToHTML(logs[i])
+		lines = append(lines, LogLine{
+			Number: i + 1,
+			HTML:   rendered,
+			Error:  errorLineRe.MatchString(plain),
+		})
+	}
+	return lines
+}
+
+// ansiStyle is the text style set by ANSI SGR sequences
+type ansiStyle struct {
+	fg, bg    int // 30-37 or 90-97 and 40-47 or 100-107, 0 for the default
+	bold      bool
+	italic    bool
+	underline bool
+}
+
+// classes returns the CSS classes of the style
+func (s ansiStyle) classes() string {
+	var classes []string
+	if s.fg != 0 {
+		classes = append(classes, fmt.Sprintf("ansi-fg-%d", s.fg))
+	}
+	if s.bg != 0 {
+		classes = append(classes, fmt.Sprintf("ansi-bg-%d", s.bg))
+	}
+	if s.bold {
+		classes = append(classes, "ansi-bold")
+	}
+	if s.italic {
+		classes = append(classes, "ansi-italic")
+	}
+	if s.underline {
+		classes = append(classes, "ansi-underline")
+	}
+	return strings.Join(classes, " ")
+}
+
+// apply updates the style with the parameters of an SGR sequence
+func (s *ansiStyle) apply(params string) {
+	if params == "" {
+		*s = ansiStyle{}
+		return
+	}
+	for _, p := range strings.Split(params, ";") {
+		code, err := strconv.Atoi(p)
+		if err != nil {
+			continue
+		}
+		switch {
+		case code == 0:
+			*s = ansiStyle{}
+		case code == 1:
+			s.bold = true
+		case code == 3:
+			s.italic = true
+		case code == 4:
+			s.underline = true
+		case code == 22:
+			s.bold = false
+		case code == 23:
+			s.italic = false
+		case code == 24:
+			s.underline = false
+		case code >= 30 && code <= 37, code >= 90 && code <= 97:
+			s.fg = code
+		case code == 39:
+			s.fg = 0
+		case code >= 40 && code <= 47, code >= 100 && code <= 107:
+			s.bg = code
+		case code == 49:
+			s.bg = 0
+		}
+	}
+}
+
+// ansiToHTML converts the ANSI color and style sequences in a log line to
+// spans with ansi-* CSS classes, escaping the text and dropping other escape
+// sequences. It also returns the line's text without escape sequences.
+func ansiToHTML(line string) (template.HTML, string) {
+	var b, plain strings.Builder
+	var style ansiStyle
+	open := false
+
+	write := func(text string) {
+		// Drop the escape characters of incomplete sequences
+		text = strings.ReplaceAll(text, "\x1b", "")
+		if text == "" {
+			return
+		}
+		plain.WriteString(text)
+		b.WriteString(html.EscapeString(text))
+	}
+
+	last := 0
+	for _, m := range ansiRe.FindAllStringSubmatchIndex(line, -1) {
+		write(line[last:m[0]])
+		last = m[1]
+		if line[m[4]:m[5]] != "m" {
+			continue
+		}
+		style.apply(line[m[2]:m[3]])
+		if open {
+			b.WriteString("</span>")
+			open = false
+		}
+		if classes := style.classes(); classes != "" {
+			fmt.Fprintf(&b, `<span class="%s">`, classes)
+			open = true
+		}
+	}
+	write(line[last:])
+	if open {
+		b.WriteString("</span>")
+	}
+
+	return template.HTML(b.String()), plain.String()
+}
@@ -0,0 +1,217 @@
+package testgrid
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestAnsiToHTML(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		wantHTML  string
+		wantPlain string
+	}{
+		{
+			name:      "plain text",
+			line:      "creating cluster",
+			wantHTML:  "creating cluster",
+			wantPlain: "creating cluster",
+		},
+		{
+			name:      "HTML is escaped",
+			line:      `<script>alert("x")</script> & more`,
+			wantHTML:  "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; more",
+			wantPlain: `<script>alert("x")</script> & more`,
+		},
+		{
+			name:      "HTML inside a colored span is escaped",
+			line:      "\x1b[31m<b>failed</b>\x1b[0m",
+			wantHTML:  `<span class="ansi-fg-31">&lt;b&gt;failed&lt;/b&gt;</span>`,
+			wantPlain: "<b>failed</b>",
+		},
+		{
+			name:      "color",
+			line:      "\x1b[32mok\x1b[0m done",
+			wantHTML:  `<span class="ansi-fg-32">ok</span> done`,
+			wantPlain: "ok done",
+		},
+		{
+			name:      "combined styles",
+			line:      "\x1b[1;4;33;44mwarning\x1b[m",
+			wantHTML:  `<span class="ansi-fg-33 ansi-bg-44 ansi-bold ansi-underline">warning</span>`,
+			wantPlain: "warning",
+		},
+		{
+			// Styles accumulate until reset
+			name:      "accumulated styles",
+			line:      "\x1b[1mbold \x1b[31mred\x1b[22m plain red\x1b[39m plain",
+			wantHTML:  `<span class="ansi-bold">bold </span><span class="ansi-fg-31 ansi-bold">red</span><span class="ansi-fg-31"> plain red</span> plain`,
+			wantPlain: "bold red plain red plain",
+		},
+		{
+			name:      "span closed at the end of the line",
+			line:      "\x1b[91mno reset",
+			wantHTML:  `<span class="ansi-fg-91">no reset</span>`,
+			wantPlain: "no reset",
+		},
+		{
+			name:      "reset without a style",
+			line:      "\x1b[0mtext\x1b[0m",
+			wantHTML:  "text",
+			wantPlain: "text",
+		},
+		{
+			name:      "other sequences dropped",
+			line:      "\x1b[2K\x1b[1Aprogress 50%",
+			wantHTML:  "progress 50%",
+			wantPlain: "progress 50%",
+		},
+		{
+			name:      "stray escape characters dropped",
+			line:      "a\x1bb \x1b[ c\x1b",
+			wantHTML:  "ab [ c",
+			wantPlain: "ab [ c",
+		},
+		{
+			name:      "unknown SGR codes ignored",
+			line:      "\x1b[38;5;196mtext",
+			wantHTML:  "text",
+			wantPlain: "text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotHTML, gotPlain := ansiToHTML(tt.line)
+			if string(gotHTML) != tt.wantHTML {
+				t.Errorf("ansiToHTML(%q) HTML = %s, want %s", tt.line, gotHTML, tt.wantHTML)
+			}
+			if gotPlain != tt.wantPlain {
+				t.Errorf("ansiToHTML(%q) text = %q, want %q", tt.line, gotPlain, tt.wantPlain)
+			}
+		})
+	}
+}
+
+func TestHandleTestLogs(t *testing.T) {
+	var logs []string
+	for i := 1; i <= maxLogPageLines+10; i++ {
+		logs = append(logs, fmt.Sprintf("line %d", i))
+	}
+	logs[1] = "\x1b[31mError: <nil>\x1b[0m"
+	store := &memoryStore{jobs: []Job{{
+		ID:       "1001",
+		TestName: "e2e-aws",
+		Tests:    []Test{{Name: "TestNodePool", Result: "fail", Logs: logs}},
+	}}}
+	handler, err := NewHandler(os.DirFS(".."), store)
+	if err != nil {
+		t.Fatalf("NewHandler returned error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantOffset int
+		wantLines  int
+	}{
+		{
+			name:       "first page",
+			query:      "test=TestNodePool",
+			wantStatus: http.StatusOK,
+			wantLines:  logPageLines,
+		},
+		{
+			name:       "offset and limit",
+			query:      "test=TestNodePool&offset=1&limit=3",
+			wantStatus: http.StatusOK,
+			wantOffset: 1,
+			wantLines:  3,
+		},
+		{
+			name:       "limit above the maximum",
+			query:      "test=TestNodePool&limit=100000",
+			wantStatus: http.StatusOK,
+			wantLines:  maxLogPageLines,
+		},
+		{
+			name:       "last lines",
+			query:      fmt.Sprintf("test=TestNodePool&offset=%d", maxLogPageLines+5),
+			wantStatus: http.StatusOK,
+			wantOffset: maxLogPageLines + 5,
+			wantLines:  5,
+		},
+		{
+			name:       "offset past the end",
+			query:      "test=TestNodePool&offset=100000",
+			wantStatus: http.StatusOK,
+			wantOffset: maxLogPageLines + 10,
+		},
+		{
+			name:       "zero limit",
+			query:      "test=TestNodePool&limit=0",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "negative offset",
+			query:      "test=TestNodePool&offset=-1",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid limit",
+			query:      "test=TestNodePool&limit=all",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown test",
+			query:      "test=TestUpgrade",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/?job=1001&logs&"+tt.query, nil))
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var page LogPage
+			if err := json.Unmarshal(recorder.Body.Bytes(), &page); err != nil {
+				t.Fatalf("decoding logs: %v", err)
+			}
+			if page.Test != "TestNodePool" || page.Total != len(logs) || page.Offset != tt.wantOffset || len(page.Lines) != tt.wantLines {
+				t.Errorf("page = %s with %d of %d lines from %d, want TestNodePool with %d of %d lines from %d",
+					page.Test, len(page.Lines), page.Total, page.Offset, tt.wantLines, len(logs), tt.wantOffset)
+			}
+			for i, line := range page.Lines {
+				if line.Number != tt.wantOffset+i+1 {
+					t.Errorf("line %d is numbered %d, want %d", i, line.Number, tt.wantOffset+i+1)
+					break
+				}
+			}
+		})
+	}
+
+	// Lines are rendered as in the job details page
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/?job=1001&logs&test=TestNodePool&offset=1&limit=1", nil))
+	var page LogPage
+	if err := json.Unmarshal(recorder.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decoding logs: %v", err)
+	}
+	want := LogLine{Number: 2, HTML: `<span class="ansi-fg-31">Error: &lt;nil&gt;</span>`, Error: true}
+	if len(page.Lines) != 1 || page.Lines[0] != want {
+		t.Errorf("lines = %+v, want %+v", page.Lines, want)
+	}
+}
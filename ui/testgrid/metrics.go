@@ -0,0 +1,55 @@
+package testgrid
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "testgrid_ui_request_duration_seconds",
+		Help:    "Time taken to serve UI requests, by route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route"})
+	mongoQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "testgrid_ui_mongo_query_duration_seconds",
+		Help:    "Time taken by MongoDB queries, including connecting, by query.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"query"})
+)
+
+// routeName names the view a request is dispatched to by ServeHTTP, for
+// metrics. It checks the query parameters in the same order as ServeHTTP.
+func routeName(query url.Values) string {
+	switch {
+	case query.Get("job") != "" && query.Has("logs"):
+		return "logs"
+	case query.Get("job") != "":
+		return "job"
+	case query.Get("compare") != "":
+		return "compare"
+	case query.Get("history") != "":
+		return "history"
+	case query.Has("regressions"):
+		return "regressions"
+	case query.Has("signatures"):
+		return "signatures"
+	case query.Has("search"):
+		return "search"
+	case query.Get("pr") != "" && query.Get("testName") == "":
+		return "pr"
+	case query.Get("testName") != "":
+		return "grid"
+	default:
+		return "testnames"
+	}
+}
+
+// observeMongoQuery records the duration of a MongoDB query started at start.
+// It is meant to be deferred.
+func observeMongoQuery(query string, start time.Time) {
+	mongoQueryDuration.WithLabelValues(query).Observe(time.Since(start).Seconds())
+}
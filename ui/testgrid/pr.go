@@ -0,0 +1,246 @@
+package testgrid
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PRViewModel represents the data for the PR dashboard
+type PRViewModel struct {
+	PR           int
+	Runs         int          // Number of jobs across all test names
+	Commits      []PRCommit   // Tested commits, newest first
+	TestNames    []PRTestName // Per test name (job type) summary
+	FailingTests []PRFailingTest
+}
+
+// PRCommit contains the runs of one tested commit of a PR
+type PRCommit struct {
+	SHA       string // Empty for jobs stored before the SHA was recorded
+	FirstRun  string
+	TestNames []PRCommitRuns
+}
+
+// ShortSHA returns the abbreviated commit SHA
+func (c PRCommit) ShortSHA() string {
+	if len(c.SHA) > 8 {
+		return c.SHA[:8]
+	}
+	return c.SHA
+}
+
+// PRCommitRuns contains the runs of one test name on a commit, oldest first
+type PRCommitRuns struct {
+	TestName string
+	Runs     []Job
+}
+
+// Retests returns the number of runs after the first
+func (r PRCommitRuns) Retests() int {
+	return len(r.Runs) - 1
+}
+
+// PRTestName summarizes the runs of one test name (job type) on a PR
+type PRTestName struct {
+	TestName     string
+	Runs         int
+	Passed       int
+	Failed       int
+	Retests      int // Runs beyond the first on the same commit
+	LatestResult string
+	LatestJob    Job
+}
+
+// PRFailingTest is a test that failed in at least one run of the PR
+type PRFailingTest struct {
+	Name        string
+	Failures    int
+	TestNames   []string // Test names (job types) it failed in
+	LastFailure Job      // The most recent job it failed in, without tests
+	// StillFailing is set if the test failed in the latest run of a test name
+	StillFailing bool
+}
+
+// handlePR handles the dashboard of all runs of a PR
+func (h *Handler) handlePR(w http.ResponseWriter, r *http.Request, pr int) {
+	jobs, err := h.store.PRJobs(pr)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching jobs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	viewModel := buildPRViewModel(pr, jobs)
+
+	err = h.templates.ExecuteTemplate(w, "pr.html", viewModel)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Template execution error", "url", r.URL.String(), "error", err)
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// fetchPRJobsFromMongoDB retrieves all jobs of a PR across test names, oldest
+// first, without logs and manifests
+func fetchPRJobsFromMongoDB(pr int) ([]Job, error) {
+	defer observeMongoQuery("pr_jobs", time.Now())
+
+	// MongoDB connection configuration
+	clientOptions := options.Client().ApplyURI(getMongoDBURI())
+	client, err := mongo.Connect(context.TODO(), clientOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Disconnect(context.TODO())
+
+	// Select database and collection
+	collection := client.Database("ci").Collection("jobs")
+
+	findOptions := options.Find().
+		SetProjection(gridProjection).
+		SetSort(bson.D{{Key: "started_at", Value: 1}})
+
+	cursor, err := collection.Find(context.TODO(), bson.M{"pr": pr}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var jobs []Job
+	if err = cursor.All(context.TODO(), &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// buildPRViewModel groups a PR's jobs, oldest first, by commit and test name
+// and aggregates their failing tests
+func buildPRViewModel(pr int, jobs []Job) PRViewModel {
+	viewModel := PRViewModel{PR: pr, Runs: len(jobs)}
+
+	commits := make(map[string]*PRCommit)
+	var commitOrder []string
+	testNames := make(map[string]*PRTestName)
+	latestByTestName := make(map[string]Job)
+	for _, job := range jobs {
+		commit, ok := commits[job.SHA]
+		if !ok {
+			commit = &PRCommit{SHA: job.SHA, FirstRun: job.StartedAt}
+			commits[job.SHA] = commit
+			commitOrder = append(commitOrder, job.SHA)
+		}
+		found := false
+		for i := range commit.TestNames {
+			if commit.TestNames[i].TestName == job.TestName {
+				commit.TestNames[i].Runs = append(commit.TestNames[i].Runs, job)
+				found = true
+				break
+			}
+		}
+		if !found {
+			commit.TestNames = append(commit.TestNames, PRCommitRuns{TestName: job.TestName, Runs: []Job{job}})
+		}
+
+		summary, ok := testNames[job.TestName]
+		if !ok {
+			summary = &PRTestName{TestName: job.TestName}
+			testNames[job.TestName] = summary
+		}
+		summary.Runs++
+		switch strings.ToUpper(job.Result) {
+		case "SUCCESS":
+			summary.Passed++
+		case "FAILURE":
+			summary.Failed++
+		}
+		if found {
+			summary.Retests++
+		}
+		summary.LatestResult = job.Result
+		summary.LatestJob = job
+		latestByTestName[job.TestName] = job
+	}
+
+	// Newest commits first
+	for i := len(commitOrder) - 1; i >= 0; i-- {
+		commit := commits[commitOrder[i]]
+		sort.Slice(commit.TestNames, func(a, b int) bool {
+			return commit.TestNames[a].TestName < commit.TestNames[b].TestName
+		})
+		viewModel.Commits = append(viewModel.Commits, *commit)
+	}
+
+	for _, summary := range testNames {
+		viewModel.TestNames = append(viewModel.TestNames, *summary)
+	}
+	sort.Slice(viewModel.TestNames, func(i, j int) bool {
+		return viewModel.TestNames[i].TestName < viewModel.TestNames[j].TestName
+	})
+
+	viewModel.FailingTests = aggregateFailingTests(jobs, latestByTestName)
+	return viewModel
+}
+
+// aggregateFailingTests collects the tests that failed in any of the jobs,
+// given oldest first. Tests still failing in the latest run of a test name come
+// first, then those with the most failures.
+func aggregateFailingTests(jobs []Job, latestByTestName map[string]Job) []PRFailingTest {
+	failing := make(map[string]*PRFailingTest)
+	for _, job := range jobs {
+		for _, test := range job.Tests {
+			if strings.ToLower(test.Result) != "fail" {
+				continue
+			}
+			info, ok := failing[test.Name]
+			if !ok {
+				info = &PRFailingTest{Name: test.Name}
+				failing[test.Name] = info
+			}
+			info.Failures++
+			if !containsString(info.TestNames, job.TestName) {
+				info.TestNames = append(info.TestNames, job.TestName)
+			}
+			lastFailure := job
+			lastFailure.Tests = nil
+			info.LastFailure = lastFailure
+			if latestByTestName[job.TestName].ID == job.ID {
+				info.StillFailing = true
+			}
+		}
+	}
+
+	var result []PRFailingTest
+	for _, info := range failing {
+		sort.Strings(info.TestNames)
+		result = append(result, *info)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].StillFailing != result[j].StillFailing {
+			return result[i].StillFailing
+		}
+		if result[i].Failures != result[j].Failures {
+			return result[i].Failures > result[j].Failures
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// containsString reports whether a slice contains a string
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
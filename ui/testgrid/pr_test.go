@@ -0,0 +1,122 @@
+package testgrid
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// prJobs returns the jobs of a PR, oldest first: two commits, with e2e-aws
+// retested on the first and TestNodePool still failing on e2e-aks.
+func prJobs() []Job {
+	return []Job{
+		{ID: "1", TestName: "e2e-aws", SHA: "aaaaaaaaaaaa", Result: "FAILURE", StartedAt: "2026-10-18T09:00:00Z", PR: 5021, Tests: []Test{
+			{Name: "TestCreateCluster", Result: "fail"},
+			{Name: "TestNodePool", Result: "pass"},
+		}},
+		{ID: "2", TestName: "e2e-aws", SHA: "aaaaaaaaaaaa", Result: "SUCCESS", StartedAt: "2026-10-18T10:00:00Z", PR: 5021, Tests: []Test{
+			{Name: "TestCreateCluster", Result: "pass"},
+			{Name: "TestNodePool", Result: "pass"},
+		}},
+		{ID: "3", TestName: "e2e-aks", SHA: "aaaaaaaaaaaa", Result: "FAILURE", StartedAt: "2026-10-18T10:30:00Z", PR: 5021, Tests: []Test{
+			{Name: "TestNodePool", Result: "fail"},
+		}},
+		{ID: "4", TestName: "e2e-aks", SHA: "bbbbbbbbbbbb", Result: "FAILURE", StartedAt: "2026-10-18T12:00:00Z", PR: 5021, Tests: []Test{
+			{Name: "TestNodePool", Result: "FAIL"},
+		}},
+	}
+}
+
+func TestBuildPRViewModel(t *testing.T) {
+	viewModel := buildPRViewModel(5021, prJobs())
+
+	if viewModel.PR != 5021 || viewModel.Runs != 4 {
+		t.Errorf("PR %d with %d runs, want 5021 with 4", viewModel.PR, viewModel.Runs)
+	}
+
+	// Newest commit first, with the runs of each test name oldest first
+	var commits []string
+	for _, commit := range viewModel.Commits {
+		var testNames []string
+		for _, runs := range commit.TestNames {
+			var ids []string
+			for _, job := range runs.Runs {
+				ids = append(ids, job.ID)
+			}
+			testNames = append(testNames, fmt.Sprintf("%s%v+%d", runs.TestName, ids, runs.Retests()))
+		}
+		commits = append(commits, fmt.Sprintf("%s %s %v", commit.ShortSHA(), commit.FirstRun, testNames))
+	}
+	wantCommits := []string{
+		"bbbbbbbb 2026-10-18T12:00:00Z [e2e-aks[4]+0]",
+		"aaaaaaaa 2026-10-18T09:00:00Z [e2e-aks[3]+0 e2e-aws[1 2]+1]",
+	}
+	if !slices.Equal(commits, wantCommits) {
+		t.Errorf("commits = %q, want %q", commits, wantCommits)
+	}
+
+	var testNames []string
+	for _, s := range viewModel.TestNames {
+		testNames = append(testNames, fmt.Sprintf("%s runs=%d passed=%d failed=%d retests=%d latest=%s/%s", s.TestName, s.Runs, s.Passed, s.Failed, s.Retests, s.LatestJob.ID, s.LatestResult))
+	}
+	wantTestNames := []string{
+		"e2e-aks runs=2 passed=0 failed=2 retests=0 latest=4/FAILURE",
+		"e2e-aws runs=2 passed=1 failed=1 retests=1 latest=2/SUCCESS",
+	}
+	if !slices.Equal(testNames, wantTestNames) {
+		t.Errorf("test names = %q, want %q", testNames, wantTestNames)
+	}
+
+	// Tests still failing in the latest run of a test name come first
+	var failing []string
+	for _, f := range viewModel.FailingTests {
+		failing = append(failing, fmt.Sprintf("%s failures=%d %v last=%s still=%v", f.Name, f.Failures, f.TestNames, f.LastFailure.ID, f.StillFailing))
+		if f.LastFailure.Tests != nil {
+			t.Errorf("last failure of %s has tests", f.Name)
+		}
+	}
+	wantFailing := []string{
+		"TestNodePool failures=2 [e2e-aks] last=4 still=true",
+		"TestCreateCluster failures=1 [e2e-aws] last=1 still=false",
+	}
+	if !slices.Equal(failing, wantFailing) {
+		t.Errorf("failing tests = %q, want %q", failing, wantFailing)
+	}
+}
+
+func TestBuildPRViewModelWithoutSHA(t *testing.T) {
+	// Jobs stored before the SHA was recorded share one commit
+	jobs := prJobs()
+	for i := range jobs {
+		jobs[i].SHA = ""
+	}
+	viewModel := buildPRViewModel(5021, jobs)
+	if len(viewModel.Commits) != 1 || viewModel.Commits[0].SHA != "" || len(viewModel.Commits[0].TestNames) != 2 {
+		t.Errorf("commits = %+v, want a single commit without a SHA", viewModel.Commits)
+	}
+}
+
+func TestPRTemplate(t *testing.T) {
+	h, err := NewHandler(os.DirFS(".."), &memoryStore{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf strings.Builder
+	if err := h.templates.ExecuteTemplate(&buf, "pr.html", buildPRViewModel(5021, prJobs())); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	for _, want := range []string{"5021", "bbbbbbbb", "e2e-aks", "e2e-aws", "TestNodePool", "TestCreateCluster", "?job=4"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("PR page doesn't contain %q", want)
+		}
+	}
+
+	// A PR without jobs renders too
+	buf.Reset()
+	if err := h.templates.ExecuteTemplate(&buf, "pr.html", buildPRViewModel(5022, nil)); err != nil {
+		t.Fatal(err)
+	}
+}
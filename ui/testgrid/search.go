@@ -0,0 +1,292 @@
+package testgrid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// maxSearchResults caps the number of failed tests a search returns
+	maxSearchResults = 100
+	// maxSnippetsPerTest caps the number of matching log lines shown per test
+	maxSnippetsPerTest = 5
+)
+
+// SearchViewModel represents the data for the log search view
+type SearchViewModel struct {
+	Query          string // The search text
+	FilterTestName string // The test name (job type) searched, if any
+	FilterTest     string // Substring of the individual test names searched, if any
+	From           string
+	To             string
+	Searched       bool // Whether a search was run, as opposed to showing the form
+	Results        []SearchResult
+	Truncated      bool // Whether more tests matched than are listed
+}
+
+// SearchResult is a failed test whose logs match a search
+type SearchResult struct {
+	JobID       string          `json:"job_id"`
+	TestName    string          `json:"test_name"`
+	StartedAt   string          `json:"started_at"`
+	PR          int             `json:"pr"`
+	JobLink     string          `json:"job_link,omitempty"`
+	Test        string          `json:"test"`
+	Snippets    []SearchSnippet `json:"snippets"`
+	MoreMatches int             `json:"more_matches,omitempty"` // Matching lines not included in Snippets
+	URL         string          `json:"url"`                    // Job details page expanded to the test
+}
+
+// SearchSnippet is a matching log line
+type SearchSnippet struct {
+	Line int    `json:"line"` // 1-based line number in the test's logs
+	Text string `json:"text"`
+}
+
+// SearchQuery selects the failure logs to search
+type SearchQuery struct {
+	Terms    []string // Words or phrases that must all appear in a test's logs
+	TestName string   // Only jobs of this test name (job type), if set
+	Test     string   // Only tests whose name contains this, if set
+	Window   TimeWindow
+}
+
+// handleSearch handles the failure log search page and its JSON API
+func (h *Handler) handleSearch(w http.ResponseWriter, r *http.Request) {
+	window, err := parseTimeWindow(r.URL.Query(), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	viewModel := SearchViewModel{
+		Query:          r.URL.Query().Get("search"),
+		FilterTestName: r.URL.Query().Get("testName"),
+		FilterTest:     r.URL.Query().Get("test"),
+		From:           window.From.Format(dateLayout),
+		To:             r.URL.Query().Get("to"),
+	}
+
+	query := SearchQuery{
+		Terms:    parseSearchTerms(viewModel.Query),
+		TestName: viewModel.FilterTestName,
+		Test:     viewModel.FilterTest,
+		Window:   window,
+	}
+	if len(query.Terms) > 0 {
+		results, truncated, err := collectSearchResults(query, h.store.SearchJobs)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error searching logs: %v", err), http.StatusInternalServerError)
+			return
+		}
+		viewModel.Searched = true
+		viewModel.Results = results
+		viewModel.Truncated = truncated
+	}
+
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(struct {
+			Results   []SearchResult `json:"results"`
+			Truncated bool           `json:"truncated"`
+		}{viewModel.Results, viewModel.Truncated}); err != nil {
+			slog.ErrorContext(r.Context(), "Error encoding search results", "url", r.URL.String(), "error", err)
+		}
+		return
+	}
+
+	err = h.templates.ExecuteTemplate(w, "search.html", viewModel)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Template execution error", "url", r.URL.String(), "error", err)
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// parseSearchTerms splits search text into words and double-quoted phrases
+func parseSearchTerms(text string) []string {
+	var terms []string
+	for i, part := range strings.Split(text, `"`) {
+		if i%2 == 1 {
+			// Inside quotes
+			if phrase := strings.Join(strings.Fields(part), " "); phrase != "" {
+				terms = append(terms, phrase)
+			}
+			continue
+		}
+		terms = append(terms, strings.Fields(part)...)
+	}
+	return terms
+}
+
+// textSearch builds a $text search string that requires every term. MongoDB
+// combines quoted phrases with AND, while unquoted words are combined with OR.
+func textSearch(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + term + `"`
+	}
+	return strings.Join(quoted, " ")
+}
+
+// collectSearchResults runs a search, visiting jobs newest first until more
+// than maxSearchResults failed tests match, and returns up to
+// maxSearchResults of them along with whether there were more. The limit
+// applies to matching tests rather than jobs, since most jobs the text index
+// returns have no failed test matching every term.
+func collectSearchResults(query SearchQuery, search func(SearchQuery, func(Job) bool) error) ([]SearchResult, bool, error) {
+	var results []SearchResult
+	err := search(query, func(job Job) bool {
+		results = append(results, searchResults([]Job{job}, query)...)
+		return len(results) <= maxSearchResults
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if len(results) > maxSearchResults {
+		return results[:maxSearchResults], true, nil
+	}
+	return results, false, nil
+}
+
+// searchJobsInMongoDB calls visit with each job whose test logs contain all
+// search terms, newest first, until visit returns false.
+func searchJobsInMongoDB(query SearchQuery, visit func(Job) bool) error {
+	defer observeMongoQuery("search", time.Now())
+
+	// MongoDB connection configuration
+	clientOptions := options.Client().ApplyURI(getMongoDBURI())
+	client, err := mongo.Connect(context.TODO(), clientOptions)
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect(context.TODO())
+
+	// Select database and collection
+	collection := client.Database("ci").Collection("jobs")
+
+	filter := bson.M{
+		"$text":      bson.M{"$search": textSearch(query.Terms)},
+		"started_at": query.Window.mongoFilter(),
+	}
+	if query.TestName != "" {
+		filter["test_name"] = query.TestName
+	}
+	if query.Test != "" {
+		// Skip jobs that can't match in the database
+		filter["tests.name"] = bson.M{"$regex": regexp.QuoteMeta(query.Test), "$options": "i"}
+	}
+	projection := bson.M{
+		"tests.hosted_cluster": 0,
+		"tests.nodepools":      0,
+	}
+	findOptions := options.Find().
+		SetProjection(projection).
+		SetSort(bson.D{{Key: "started_at", Value: -1}})
+
+	cursor, err := collection.Find(context.TODO(), filter, findOptions)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.TODO())
+
+	for cursor.Next(context.TODO()) {
+		var job Job
+		if err := cursor.Decode(&job); err != nil {
+			return err
+		}
+		if !visit(job) {
+			return nil
+		}
+	}
+	return cursor.Err()
+}
+
+// searchResults finds the failed tests in jobs whose logs contain all search
+// terms, matching case-insensitively, and collects the lines that contain any
+// of them
+func searchResults(jobs []Job, query SearchQuery) []SearchResult {
+	terms := make([]string, len(query.Terms))
+	for i, term := range query.Terms {
+		terms[i] = strings.ToLower(term)
+	}
+	filterTest := strings.ToLower(query.Test)
+
+	var results []SearchResult
+	for _, job := range jobs {
+		for _, test := range job.Tests {
+			if strings.ToLower(test.Result) != "fail" {
+				continue
+			}
+			if filterTest != "" && !strings.Contains(strings.ToLower(test.Name), filterTest) {
+				continue
+			}
+
+			found := make([]bool, len(terms))
+			var matches []SearchSnippet
+			for i, line := range test.Logs {
+				lower := strings.ToLower(line)
+				matched := false
+				for j, term := range terms {
+					if strings.Contains(lower, term) {
+						found[j] = true
+						matched = true
+					}
+				}
+				if matched {
+					matches = append(matches, SearchSnippet{Line: i + 1, Text: line})
+				}
+			}
+			if !allTrue(found) {
+				continue
+			}
+
+			result := SearchResult{
+				JobID:     job.ID,
+				TestName:  job.TestName,
+				StartedAt: job.StartedAt,
+				PR:        job.PR,
+				JobLink:   job.JobLink,
+				Test:      test.Name,
+				Snippets:  matches,
+				URL:       jobDetailsURL(job, test.Name),
+			}
+			if len(matches) > maxSnippetsPerTest {
+				result.Snippets = matches[:maxSnippetsPerTest]
+				result.MoreMatches = len(matches) - maxSnippetsPerTest
+			}
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// allTrue reports whether every value is true
+func allTrue(values []bool) bool {
+	for _, v := range values {
+		if !v {
+			return false
+		}
+	}
+	return true
+}
+
+// jobDetailsURL links to the job details page with a test expanded
+func jobDetailsURL(job Job, test string) string {
+	query := url.Values{}
+	query.Set("job", job.ID)
+	query.Set("testName", job.TestName)
+	query.Set("test", test)
+	return "/?" + query.Encode()
+}
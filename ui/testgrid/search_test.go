@@ -0,0 +1,209 @@
+package testgrid
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestParseSearchTerms(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "", want: nil},
+		{text: "   ", want: nil},
+		{text: "timeout", want: []string{"timeout"}},
+		{text: "  context   deadline ", want: []string{"context", "deadline"}},
+		{text: `"context deadline exceeded"`, want: []string{"context deadline exceeded"}},
+		{text: `etcd "context   deadline" exceeded`, want: []string{"etcd", "context deadline", "exceeded"}},
+		{text: `"" empty`, want: []string{"empty"}},
+		// An unterminated quote runs to the end of the text
+		{text: `etcd "leader changed`, want: []string{"etcd", "leader changed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := parseSearchTerms(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("parseSearchTerms(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextSearch(t *testing.T) {
+	tests := []struct {
+		terms []string
+		want  string
+	}{
+		{terms: []string{"timeout"}, want: `"timeout"`},
+		{terms: []string{"etcd", "context deadline"}, want: `"etcd" "context deadline"`},
+	}
+
+	for _, tt := range tests {
+		if got := textSearch(tt.terms); got != tt.want {
+			t.Errorf("textSearch(%q) = %s, want %s", tt.terms, got, tt.want)
+		}
+	}
+}
+
+func TestSearchResults(t *testing.T) {
+	logs := func(n int, line string) []string {
+		var lines []string
+		for i := range n {
+			lines = append(lines, fmt.Sprintf("%s %d", line, i))
+		}
+		return lines
+	}
+	jobs := []Job{
+		{
+			ID:        "1002",
+			TestName:  "e2e-aws",
+			StartedAt: "2026-10-18T12:00:00Z",
+			PR:        5021,
+			Tests: []Test{
+				{Name: "TestCreateCluster", Result: "fail", Logs: []string{"creating cluster", "ETCD leader changed", "Context deadline exceeded"}},
+				{Name: "TestNodePool", Result: "pass", Logs: []string{"etcd leader changed", "context deadline exceeded"}},
+				{Name: "TestUpgrade", Result: "FAIL", Logs: []string{"etcd leader changed"}},
+			},
+		},
+		{
+			ID:        "1001",
+			TestName:  "e2e-aks",
+			StartedAt: "2026-10-18T09:00:00Z",
+			Tests: []Test{
+				{Name: "TestNodePoolAutoRepair", Result: "fail", Logs: logs(maxSnippetsPerTest+2, "context deadline exceeded and etcd leader changed")},
+			},
+		},
+	}
+	resultTests := func(results []SearchResult) []string {
+		var tests []string
+		for _, result := range results {
+			tests = append(tests, result.JobID+"/"+result.Test)
+		}
+		return tests
+	}
+
+	tests := []struct {
+		name  string
+		query SearchQuery
+		want  []string
+	}{
+		{
+			// Terms match case-insensitively, and only failed tests are
+			// searched
+			name:  "all terms",
+			query: SearchQuery{Terms: []string{"etcd", "context deadline"}},
+			want:  []string{"1002/TestCreateCluster", "1001/TestNodePoolAutoRepair"},
+		},
+		{
+			name:  "one term",
+			query: SearchQuery{Terms: []string{"leader changed"}},
+			want:  []string{"1002/TestCreateCluster", "1002/TestUpgrade", "1001/TestNodePoolAutoRepair"},
+		},
+		{
+			name:  "term in no logs",
+			query: SearchQuery{Terms: []string{"etcd", "out of memory"}},
+		},
+		{
+			name:  "test name substring",
+			query: SearchQuery{Terms: []string{"etcd"}, Test: "nodepool"},
+			want:  []string{"1001/TestNodePoolAutoRepair"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resultTests(searchResults(jobs, tt.query)); !slices.Equal(got, tt.want) {
+				t.Errorf("searchResults = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	results := searchResults(jobs, SearchQuery{Terms: []string{"etcd", "context deadline"}})
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	// Lines that contain any term are snippets
+	want := []SearchSnippet{{Line: 2, Text: "ETCD leader changed"}, {Line: 3, Text: "Context deadline exceeded"}}
+	if got := results[0]; !slices.Equal(got.Snippets, want) || got.MoreMatches != 0 {
+		t.Errorf("snippets = %v and %d more, want %v", got.Snippets, got.MoreMatches, want)
+	}
+	if got, want := results[0].URL, "/?job=1002&test=TestCreateCluster&testName=e2e-aws"; got != want {
+		t.Errorf("URL = %s, want %s", got, want)
+	}
+	if got := results[1]; len(got.Snippets) != maxSnippetsPerTest || got.MoreMatches != 2 {
+		t.Errorf("got %d snippets and %d more, want %d and 2", len(got.Snippets), got.MoreMatches, maxSnippetsPerTest)
+	}
+}
+
+func TestCollectSearchResults(t *testing.T) {
+	// Jobs newest first, most of which have no failed test matching the
+	// query, as the text index returns
+	failed := func(id string) Job {
+		return Job{ID: id, Tests: []Test{{Name: "TestNodePool", Result: "fail", Logs: []string{"etcd leader changed"}}}}
+	}
+	var jobs []Job
+	for i := range maxSearchResults + 5 {
+		jobs = append(jobs, Job{ID: fmt.Sprintf("pass-%d", i), Tests: []Test{{Name: "TestNodePool", Result: "pass", Logs: []string{"etcd leader changed"}}}})
+		jobs = append(jobs, failed(fmt.Sprintf("fail-%d", i)))
+	}
+	query := SearchQuery{Terms: []string{"etcd"}}
+
+	tests := []struct {
+		name          string
+		jobs          []Job
+		wantResults   int
+		wantTruncated bool
+		wantVisited   int
+	}{
+		{
+			name:          "more matches than listed",
+			jobs:          jobs,
+			wantResults:   maxSearchResults,
+			wantTruncated: true,
+			// Stops once a match past the limit is found
+			wantVisited: 2 * (maxSearchResults + 1),
+		},
+		{
+			name:        "as many matches as listed",
+			jobs:        jobs[:2*maxSearchResults],
+			wantResults: maxSearchResults,
+			wantVisited: 2 * maxSearchResults,
+		},
+		{
+			name:        "no matches",
+			jobs:        jobs[:1],
+			wantVisited: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			visited := 0
+			search := func(q SearchQuery, visit func(Job) bool) error {
+				for _, job := range tt.jobs {
+					visited++
+					if !visit(job) {
+						break
+					}
+				}
+				return nil
+			}
+
+			results, truncated, err := collectSearchResults(query, search)
+			if err != nil {
+				t.Fatalf("collectSearchResults returned error: %v", err)
+			}
+			if len(results) != tt.wantResults || truncated != tt.wantTruncated {
+				t.Errorf("got %d results, truncated %v, want %d, truncated %v", len(results), truncated, tt.wantResults, tt.wantTruncated)
+			}
+			if len(results) > 0 && results[0].JobID != "fail-0" {
+				t.Errorf("first result is from job %s, want the newest, fail-0", results[0].JobID)
+			}
+			if visited != tt.wantVisited {
+				t.Errorf("visited %d jobs, want %d", visited, tt.wantVisited)
+			}
+		})
+	}
+}
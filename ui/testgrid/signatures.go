@@ -0,0 +1,158 @@
+package testgrid
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxSignatures caps the number of signatures listed
+const maxSignatures = 50
+
+// FailureSignatureInfo summarizes the failed test runs sharing a signature
+type FailureSignatureInfo struct {
+	Signature string
+	Text      string   // Normalized failure lines the signature was computed from
+	Failures  int      // Number of failed test runs with this signature
+	Tests     []string // Names of the affected tests
+	Jobs      []Job    // Affected jobs, newest first, without tests
+	FirstSeen string
+	LastSeen  string
+}
+
+// FailureSignaturesViewModel represents the data for the failure signatures view
+type FailureSignaturesViewModel struct {
+	FilterTestName  string
+	FilterSignature string // The single signature being viewed, if any
+	From            string
+	To              string
+	Signatures      []FailureSignatureInfo
+	Unsigned        int // Failed test runs without a signature
+}
+
+// handleFailureSignatures handles the list of top failure signatures
+func (h *Handler) handleFailureSignatures(w http.ResponseWriter, r *http.Request, testName string) {
+	window, err := parseTimeWindow(r.URL.Query(), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jobs, err := h.store.WindowJobs(testName, window)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching jobs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	signatures, unsigned := groupFailureSignatures(jobs)
+
+	filterSignature := r.URL.Query().Get("signature")
+	signatures = selectSignatures(signatures, filterSignature)
+
+	viewModel := FailureSignaturesViewModel{
+		FilterTestName:  testName,
+		FilterSignature: filterSignature,
+		From:            window.From.Format(dateLayout),
+		To:              r.URL.Query().Get("to"),
+		Signatures:      signatures,
+		Unsigned:        unsigned,
+	}
+
+	err = h.templates.ExecuteTemplate(w, "failuresignatures.html", viewModel)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Template execution error", "url", r.URL.String(), "error", err)
+		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// selectSignatures returns the signature matching filter, or the first
+// maxSignatures signatures if filter is empty
+func selectSignatures(signatures []FailureSignatureInfo, filter string) []FailureSignatureInfo {
+	if filter != "" {
+		var matching []FailureSignatureInfo
+		for _, s := range signatures {
+			if s.Signature == filter {
+				matching = append(matching, s)
+			}
+		}
+		return matching
+	}
+	if len(signatures) > maxSignatures {
+		return signatures[:maxSignatures]
+	}
+	return signatures
+}
+
+// groupFailureSignatures groups failed test runs by signature, most frequent
+// first, and counts the failed runs that have no signature
+func groupFailureSignatures(jobs []Job) ([]FailureSignatureInfo, int) {
+	bySignature := make(map[string]*FailureSignatureInfo)
+	seenTests := make(map[string]map[string]bool)
+	seenJobs := make(map[string]map[string]bool)
+	unsigned := 0
+
+	for _, job := range jobs {
+		for _, test := range job.Tests {
+			if strings.ToLower(test.Result) != "fail" {
+				continue
+			}
+			if test.Signature == "" {
+				unsigned++
+				continue
+			}
+
+			info, exists := bySignature[test.Signature]
+			if !exists {
+				info = &FailureSignatureInfo{
+					Signature: test.Signature,
+					Text:      test.SignatureText,
+					FirstSeen: job.StartedAt,
+					LastSeen:  job.StartedAt,
+				}
+				bySignature[test.Signature] = info
+				seenTests[test.Signature] = make(map[string]bool)
+				seenJobs[test.Signature] = make(map[string]bool)
+			}
+
+			info.Failures++
+			if job.StartedAt < info.FirstSeen {
+				info.FirstSeen = job.StartedAt
+			}
+			if job.StartedAt > info.LastSeen {
+				info.LastSeen = job.StartedAt
+			}
+			if !seenTests[test.Signature][test.Name] {
+				seenTests[test.Signature][test.Name] = true
+				info.Tests = append(info.Tests, test.Name)
+			}
+			if !seenJobs[test.Signature][job.ID] {
+				seenJobs[test.Signature][job.ID] = true
+				jobOnly := job
+				jobOnly.Tests = nil
+				info.Jobs = append(info.Jobs, jobOnly)
+			}
+		}
+	}
+
+	var signatures []FailureSignatureInfo
+	for _, info := range bySignature {
+		sort.Strings(info.Tests)
+		sort.Slice(info.Jobs, func(i, j int) bool {
+			return info.Jobs[i].StartedAt > info.Jobs[j].StartedAt
+		})
+		signatures = append(signatures, *info)
+	}
+
+	sort.Slice(signatures, func(i, j int) bool {
+		if signatures[i].Failures != signatures[j].Failures {
+			return signatures[i].Failures > signatures[j].Failures
+		}
+		return signatures[i].LastSeen > signatures[j].LastSeen
+	})
+
+	return signatures, unsigned
+}
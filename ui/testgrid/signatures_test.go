@@ -0,0 +1,93 @@
+package testgrid
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestGroupFailureSignatures(t *testing.T) {
+	jobs := []Job{
+		{ID: "1", StartedAt: "2026-10-01T10:00:00Z", Tests: []Test{
+			{Name: "TestNodePool", Result: "fail", Signature: "aaaa", SignatureText: "timed out"},
+			{Name: "TestUpgrade", Result: "FAIL", Signature: "bbbb", SignatureText: "quota exceeded"},
+			{Name: "TestCreateCluster", Result: "pass", Signature: "aaaa"},
+		}},
+		{ID: "2", StartedAt: "2026-10-03T10:00:00Z", Tests: []Test{
+			{Name: "TestNodePool", Result: "fail", Signature: "aaaa", SignatureText: "timed out"},
+			{Name: "TestAutoscaling", Result: "fail", Signature: "aaaa", SignatureText: "timed out"},
+			{Name: "TestKarpenter", Result: "fail"},
+		}},
+		{ID: "3", StartedAt: "2026-10-02T10:00:00Z", Tests: []Test{
+			{Name: "TestUpgrade", Result: "fail", Signature: "cccc", SignatureText: "panic"},
+		}},
+	}
+
+	signatures, unsigned := groupFailureSignatures(jobs)
+
+	if unsigned != 1 {
+		t.Errorf("unsigned = %d, want 1", unsigned)
+	}
+	var got []string
+	for _, s := range signatures {
+		var jobIDs []string
+		for _, job := range s.Jobs {
+			jobIDs = append(jobIDs, job.ID)
+			if job.Tests != nil {
+				t.Errorf("signature %s job %s has tests", s.Signature, job.ID)
+			}
+		}
+		got = append(got, fmt.Sprintf("%s %q %d %v %v %s..%s", s.Signature, s.Text, s.Failures, s.Tests, jobIDs, s.FirstSeen[:10], s.LastSeen[:10]))
+	}
+	// Most failures first, then most recently seen
+	want := []string{
+		`aaaa "timed out" 3 [TestAutoscaling TestNodePool] [2 1] 2026-10-01..2026-10-03`,
+		`cccc "panic" 1 [TestUpgrade] [3] 2026-10-02..2026-10-02`,
+		`bbbb "quota exceeded" 1 [TestUpgrade] [1] 2026-10-01..2026-10-01`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("signatures =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSelectSignatures(t *testing.T) {
+	var signatures []FailureSignatureInfo
+	for i := range maxSignatures + 10 {
+		signatures = append(signatures, FailureSignatureInfo{Signature: fmt.Sprintf("%04x", i)})
+	}
+
+	tests := []struct {
+		name   string
+		filter string
+		want   []FailureSignatureInfo
+	}{
+		{
+			name: "capped",
+			want: signatures[:maxSignatures],
+		},
+		{
+			// Past the cap of the unfiltered list
+			name:   "filtered",
+			filter: signatures[maxSignatures+5].Signature,
+			want:   signatures[maxSignatures+5 : maxSignatures+6],
+		},
+		{
+			name:   "unknown signature",
+			filter: "ffff",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectSignatures(signatures, tt.filter); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectSignatures(%q) returned %d signatures, want %d", tt.filter, len(got), len(tt.want))
+			}
+		})
+	}
+
+	// Short lists are returned whole
+	if got := selectSignatures(signatures[:3], ""); len(got) != 3 {
+		t.Errorf("selectSignatures of 3 signatures returned %d", len(got))
+	}
+}
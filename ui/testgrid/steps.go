@@ -0,0 +1,71 @@
+package testgrid
+
+import (
+	"fmt"
+	"time"
+)
+
+// Step is a step of a ci-operator multi-stage test
+type Step struct {
+	Name      string        `json:"name" bson:"name"`
+	Result    string        `json:"result" bson:"result"` // SUCCESS, FAILURE or UNKNOWN if it didn't finish
+	StartedAt string        `json:"started_at" bson:"started_at"`
+	Duration  time.Duration `json:"duration" bson:"duration"`
+}
+
+// TimelineStep is a step placed on the job details page's timeline
+type TimelineStep struct {
+	Step
+	// Offset and Width place the step's bar, as percentages of the time from
+	// the start of the first step to the end of the last one
+	Offset string
+	Width  string
+	LogURL string // The step's build log
+}
+
+// minStepWidth keeps the bars of short and unfinished steps visible, in
+// percent
+const minStepWidth = 0.5
+
+// stepTimeline places a job's steps on a timeline
+func stepTimeline(job Job) []TimelineStep {
+	if len(job.Steps) == 0 {
+		return nil
+	}
+
+	starts := make([]time.Time, len(job.Steps))
+	var first, last time.Time
+	for i, step := range job.Steps {
+		start, err := time.Parse(time.RFC3339, step.StartedAt)
+		if err != nil {
+			continue
+		}
+		starts[i] = start
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if end := start.Add(step.Duration); end.After(last) {
+			last = end
+		}
+	}
+	total := last.Sub(first)
+
+	timeline := make([]TimelineStep, len(job.Steps))
+	for i, step := range job.Steps {
+		offset, width := 0.0, 100.0
+		if total > 0 && !starts[i].IsZero() {
+			offset = min(100*float64(starts[i].Sub(first))/float64(total), 100-minStepWidth)
+			width = max(100*float64(step.Duration)/float64(total), minStepWidth)
+			width = min(width, 100-offset)
+		}
+		timeline[i] = TimelineStep{
+			Step:   step,
+			Offset: fmt.Sprintf("%.2f", offset),
+			Width:  fmt.Sprintf("%.2f", width),
+		}
+		if job.ArtifactsURL != "" {
+			timeline[i].LogURL = job.ArtifactsURL + step.Name + "/build-log.txt"
+		}
+	}
+	return timeline
+}
@@ -0,0 +1,74 @@
+package testgrid
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stepsJob returns a job whose steps took 100 minutes, the last of which
+// never finished
+func stepsJob() Job {
+	return Job{
+		ID:           "1979000000000000000",
+		ArtifactsURL: "https://gcsweb.example.com/gcs/logs/e2e-aws/1979000000000000000/artifacts/e2e-aws/",
+		Steps: []Step{
+			{Name: "ipi-install-install", Result: "SUCCESS", StartedAt: "2026-10-18T09:00:00Z", Duration: 40 * time.Minute},
+			{Name: "hypershift-aws-run-e2e-nested", Result: "FAILURE", StartedAt: "2026-10-18T09:40:00Z", Duration: time.Hour},
+			{Name: "hypershift-dump", Result: "UNKNOWN", StartedAt: "2026-10-18T10:40:00Z"},
+		},
+	}
+}
+
+func TestStepTimeline(t *testing.T) {
+	var got []string
+	for _, step := range stepTimeline(stepsJob()) {
+		got = append(got, fmt.Sprintf("%s %s+%s %s", step.Name, step.Offset, step.Width, step.LogURL))
+	}
+	dir := stepsJob().ArtifactsURL
+	want := []string{
+		"ipi-install-install 0.00+40.00 " + dir + "ipi-install-install/build-log.txt",
+		"hypershift-aws-run-e2e-nested 40.00+60.00 " + dir + "hypershift-aws-run-e2e-nested/build-log.txt",
+		// Unfinished steps keep a visible bar
+		"hypershift-dump 99.50+0.50 " + dir + "hypershift-dump/build-log.txt",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("timeline =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Jobs scraped before their artifacts directory was recorded have no
+	// step links
+	job := stepsJob()
+	job.ArtifactsURL = ""
+	for _, step := range stepTimeline(job) {
+		if step.LogURL != "" {
+			t.Errorf("step %s links to %s without an artifacts directory", step.Name, step.LogURL)
+		}
+	}
+
+	if timeline := stepTimeline(Job{}); timeline != nil {
+		t.Errorf("timeline of a job without steps = %+v, want none", timeline)
+	}
+}
+
+func TestJobDetailsSteps(t *testing.T) {
+	h, err := NewHandler(os.DirFS(".."), &memoryStore{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := stepsJob()
+	viewModel := JobDetailsViewModel{Job: job, Steps: stepTimeline(job)}
+	var buf strings.Builder
+	if err := h.templates.ExecuteTemplate(&buf, "jobdetails.html", viewModel); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	for _, want := range []string{"<h2>Steps</h2>", job.ArtifactsURL + "hypershift-aws-run-e2e-nested/build-log.txt"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("job details page doesn't contain %q", want)
+		}
+	}
+}
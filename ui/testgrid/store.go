@@ -0,0 +1,83 @@
+package testgrid
+
+import "time"
+
+// JobStore retrieves the jobs rendered by the UI's pages and exported as
+// stats. Tests render them from an in-memory store.
+type JobStore interface {
+	// GridJobs returns a page of jobs, newest first, and reports whether
+	// there are older jobs after the page
+	GridJobs(query GridQuery) ([]Job, bool, error)
+	// Job returns the job with the given ID
+	Job(id string) (*Job, error)
+	// PreviousJobID returns the ID of the job of the same test name and PR
+	// that started last before job, or an empty string if there is none
+	PreviousJobID(job Job) (string, error)
+	// TestHistory returns the jobs of a test name in the time window that
+	// ran the given test, with only that test in their Tests
+	TestHistory(testName, test string, window TimeWindow) ([]Job, error)
+	// WindowJobs returns every job of a test name in the time window, without
+	// logs or artifacts
+	WindowJobs(testName string, window TimeWindow) ([]Job, error)
+	// SearchJobs calls visit with each job whose test logs contain all
+	// search terms, newest first, until visit returns false
+	SearchJobs(query SearchQuery, visit func(Job) bool) error
+	// PRJobs returns all jobs of a PR across test names, oldest first,
+	// without logs and manifests
+	PRJobs(pr int) ([]Job, error)
+	// LandingJobs returns the start time of the latest job of every test
+	// name, and the jobs started since the given time, newest first, with
+	// only their tests' names and results
+	LandingJobs(since time.Time) (map[string]string, []Job, error)
+	// StatsJobs returns the jobs started since the given time, newest first,
+	// with only their tests' names and results
+	StatsJobs(since time.Time) ([]Job, error)
+}
+
+// MongoStore is the JobStore of the jobs the scraper stores in MongoDB
+type MongoStore struct{}
+
+// GridJobs implements JobStore
+func (MongoStore) GridJobs(query GridQuery) ([]Job, bool, error) {
+	return fetchJobsFromMongoDB(query)
+}
+
+// Job implements JobStore
+func (MongoStore) Job(id string) (*Job, error) {
+	return fetchJobFromMongoDB(id)
+}
+
+// PreviousJobID implements JobStore
+func (MongoStore) PreviousJobID(job Job) (string, error) {
+	return fetchPreviousJobID(job)
+}
+
+// TestHistory implements JobStore
+func (MongoStore) TestHistory(testName, test string, window TimeWindow) ([]Job, error) {
+	return fetchTestHistoryFromMongoDB(testName, test, window)
+}
+
+// WindowJobs implements JobStore
+func (MongoStore) WindowJobs(testName string, window TimeWindow) ([]Job, error) {
+	return fetchWindowJobsFromMongoDB(testName, window)
+}
+
+// SearchJobs implements JobStore
+func (MongoStore) SearchJobs(query SearchQuery, visit func(Job) bool) error {
+	return searchJobsInMongoDB(query, visit)
+}
+
+// PRJobs implements JobStore
+func (MongoStore) PRJobs(pr int) ([]Job, error) {
+	return fetchPRJobsFromMongoDB(pr)
+}
+
+// LandingJobs implements JobStore
+func (MongoStore) LandingJobs(since time.Time) (map[string]string, []Job, error) {
+	return fetchLandingJobsFromMongoDB(since)
+}
+
+// StatsJobs implements JobStore
+func (MongoStore) StatsJobs(since time.Time) ([]Job, error) {
+	return fetchStatsJobsFromMongoDB(since)
+}
@@ -2,13 +2,14 @@ package testgrid
 
 import (
 	"context"
-	"embed"
 	"fmt"
 	"html/template"
-	"log"
+	"io/fs"
+	"log/slog"
 	"net/http"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -28,6 +29,12 @@ type Job struct {
 	Tests     []Test `json:"tests" bson:"tests"`
 	JobLink   string `json:"job_link" bson:"job_link"`
 	TestName  string `json:"test_name" bson:"test_name"`
+	SHA       string `json:"sha,omitempty" bson:"sha,omitempty"` // The PR head commit tested
+	// FailureStage and FailureReason explain failed jobs without failed tests
+	FailureStage  string `json:"failure_stage,omitempty" bson:"failure_stage,omitempty"`
+	FailureReason string `json:"failure_reason,omitempty" bson:"failure_reason,omitempty"`
+	ArtifactsURL  string `json:"artifacts_url,omitempty" bson:"artifacts_url,omitempty"` // The directory of the test's steps' artifacts
+	Steps         []Step `json:"steps,omitempty" bson:"steps,omitempty"`                 // In the order they started
 }
 
 // Test represents individual test details
@@ -38,6 +45,9 @@ type Test struct {
 	Logs          []string      `json:"logs" bson:"logs"`
 	HostedCluster interface{}   `json:"hosted_cluster" bson:"hosted_cluster"`
 	NodePools     interface{}   `json:"nodepools" bson:"nodepools"`
+	Signature     string        `json:"signature,omitempty" bson:"signature,omitempty"`
+	SignatureText string        `json:"signature_text,omitempty" bson:"signature_text,omitempty"`
+	InfraReason   string        `json:"-" bson:"-"` // Why the job failed, for synthetic infrastructure tests
 }
 
 // TestGridViewModel represents the data for the test grid view
@@ -48,12 +58,29 @@ type TestGridViewModel struct {
 	FilterTestName string // The test name being viewed
 	Filtered       bool   // Whether we're currently filtering
 	Title          string // The title to display for the grid
+	From           string // Start of the time window, as a date
+	To             string // End of the time window as requested, if any
+	Page           int    // The 1-based page of job columns shown
+	NewerURL       string // Link to the page of newer jobs, if any
+	OlderURL       string // Link to the page of older jobs, if any
+	DurationMode   bool   // Whether cells are colored by duration instead of result
+	ResultsURL     string // Link to the grid colored by result
+	DurationsURL   string // Link to the grid colored by duration
+	WindowPresets  []WindowPreset
+	Filter         GridFilter // Row and column filters
+	ClearFilterURL string     // Link to the grid without row and column filters
+
+	// Baselines holds each test's median passing duration, in duration mode
+	Baselines map[string]time.Duration
 }
 
 // TestResultInfo contains additional test result information
 type TestResultInfo struct {
-	Result string
-	Logs   []string
+	Result      string
+	Logs        []string
+	Duration    time.Duration
+	Ran         bool   // Whether the job ran the test at all
+	InfraReason string // Set for synthetic infrastructure tests
 }
 
 // TestGroupInfo contains information about a test group for sorting
@@ -67,8 +94,15 @@ type TestGroupInfo struct {
 type JobDetailsViewModel struct {
 	Job         Job
 	Summary     TestSummary
-	FailedTests []Test
+	FailedTests []FailedTest
 	ExpandTest  string // The name of the test to expand, if any
+	PreviousJob string // ID of the previous job of the same PR, if any
+	Steps       []TimelineStep
+}
+
+// LogPageLines returns how many log lines the job details page loads at once
+func (JobDetailsViewModel) LogPageLines() int {
+	return logPageLines
 }
 
 // TestSummary contains statistics about test results
@@ -82,15 +116,22 @@ type TestSummary struct {
 // Handler handles the testgrid HTTP requests
 type Handler struct {
 	templates *template.Template
+	store     JobStore
 }
 
-// NewHandler creates a new testgrid handler
-func NewHandler(templateFS embed.FS) (*Handler, error) {
+// NewHandler creates a new testgrid handler rendering the jobs of store
+func NewHandler(templateFS fs.FS, store JobStore) (*Handler, error) {
 	tmpl, err := template.New("").Funcs(template.FuncMap{
-		"getTestResultInfo": getTestResultInfo,
-		"formatTime":        formatTime,
-		"getJobStatusColor": getJobStatusColor,
-	}).ParseFS(templateFS, "templates/testgrid.html", "templates/jobdetails.html", "templates/testnames.html")
+		"getTestResultInfo":   getTestResultInfo,
+		"formatTime":          formatTime,
+		"getJobStatusColor":   getJobStatusColor,
+		"formatDuration":      formatDuration,
+		"formatShortDuration": formatShortDuration,
+		"durationClass":       durationClass,
+		"formatDurationDelta": formatDurationDelta,
+		"dict":                dict,
+		"isInfraRow":          isInfraRow,
+	}).ParseFS(templateFS, "templates/testgrid.html", "templates/jobdetails.html", "templates/testnames.html", "templates/testhistory.html", "templates/durationregressions.html", "templates/failuresignatures.html", "templates/search.html", "templates/compare.html", "templates/pr.html")
 
 	if err != nil {
 		return nil, fmt.Errorf("error parsing templates: %v", err)
@@ -98,17 +139,71 @@ func NewHandler(templateFS embed.FS) (*Handler, error) {
 
 	return &Handler{
 		templates: tmpl,
+		store:     store,
 	}, nil
 }
 
 // ServeHTTP implements the http.Handler interface
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	defer func() {
+		route := routeName(r.URL.Query())
+		requestDuration.WithLabelValues(route).Observe(time.Since(start).Seconds())
+		slog.DebugContext(r.Context(), "Served request", "route", route, "url", r.URL.String(), "duration", time.Since(start))
+	}()
+
 	// Check if this is a job details request
 	if jobID := r.URL.Query().Get("job"); jobID != "" {
+		// Check if this is a request for a page of a test's logs
+		if r.URL.Query().Has("logs") {
+			h.handleTestLogs(w, r, jobID)
+			return
+		}
 		h.handleJobDetails(w, r, jobID)
 		return
 	}
 
+	// Check if this is a job comparison request
+	if compare := r.URL.Query().Get("compare"); compare != "" {
+		h.handleCompare(w, r, compare)
+		return
+	}
+
+	// Check if this is a test history request
+	if test := r.URL.Query().Get("history"); test != "" {
+		h.handleTestHistory(w, r, r.URL.Query().Get("testName"), test)
+		return
+	}
+
+	// Check if this is a duration regressions request
+	if r.URL.Query().Has("regressions") {
+		h.handleDurationRegressions(w, r, r.URL.Query().Get("testName"))
+		return
+	}
+
+	// Check if this is a failure signatures request
+	if r.URL.Query().Has("signatures") {
+		h.handleFailureSignatures(w, r, r.URL.Query().Get("testName"))
+		return
+	}
+
+	// Check if this is a log search request
+	if r.URL.Query().Has("search") {
+		h.handleSearch(w, r)
+		return
+	}
+
+	// Check if this is a PR dashboard request, i.e. a PR without a test name
+	if prStr := r.URL.Query().Get("pr"); prStr != "" && r.URL.Query().Get("testName") == "" {
+		pr, err := strconv.Atoi(prStr)
+		if err != nil || pr <= 0 {
+			http.Error(w, fmt.Sprintf("invalid pr parameter: %q", prStr), http.StatusBadRequest)
+			return
+		}
+		h.handlePR(w, r, pr)
+		return
+	}
+
 	// Handle the main test grid view
 	h.handleTestGrid(w, r)
 }
@@ -126,12 +221,7 @@ func (h *Handler) handleTestGrid(w http.ResponseWriter, r *http.Request) {
 
 	// If no testName is specified, show the test name selection page
 	if !filtered {
-		err := h.templates.ExecuteTemplate(w, "testnames.html", nil)
-		if err != nil {
-			log.Printf("Template execution error: %v", err)
-			http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
-			return
-		}
+		h.handleTestNames(w, r)
 		return
 	}
 
@@ -142,8 +232,31 @@ func (h *Handler) handleTestGrid(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Fetch jobs from MongoDB filtered by testName and PR
-	jobs, err := fetchJobsFromMongoDB(filterTestName, filterPR)
+	window, err := parseTimeWindow(r.URL.Query(), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, pageSize, err := parsePage(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	gridFilter, err := parseGridFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch one page of jobs filtered by testName, PR and time window
+	jobs, hasOlder, err := h.store.GridJobs(GridQuery{
+		TestName:   filterTestName,
+		PR:         filterPR,
+		FailedOnly: gridFilter.FailedJobsOnly,
+		Window:     window,
+		Page:       page,
+		PageSize:   pageSize,
+	})
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error fetching jobs: %v", err), http.StatusInternalServerError)
 		return
@@ -156,20 +269,47 @@ func (h *Handler) handleTestGrid(w http.ResponseWriter, r *http.Request) {
 		return timeI.After(timeJ)
 	})
 
+	// Show the jobs that failed outside of their tests in synthetic rows
+	addInfraRows(jobs)
+
 	// Prepare view model
 	viewModel := TestGridViewModel{
 		Jobs:           jobs,
-		TestGroups:     extractTestGroups(jobs),
+		TestGroups:     gridFilter.filterTestGroups(extractTestGroups(jobs), jobs),
 		FilterPR:       filterPR,
 		FilterTestName: filterTestName,
 		Filtered:       filtered,
 		Title:          fmt.Sprintf("TestGrid: %s", filterTestName),
+		From:           window.From.Format(dateLayout),
+		To:             r.URL.Query().Get("to"),
+		Page:           page,
+		DurationMode:   r.URL.Query().Get("mode") == "duration",
+		ResultsURL:     withQuery(r, "mode", ""),
+		DurationsURL:   withQuery(r, "mode", "duration"),
+		WindowPresets:  windowPresets(r),
+		Filter:         gridFilter,
+		ClearFilterURL: clearFilterURL(r),
+	}
+	if viewModel.DurationMode {
+		// Baselines cover the whole window, not just the jobs on this page
+		windowJobs, err := h.store.WindowJobs(filterTestName, window)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error fetching jobs: %v", err), http.StatusInternalServerError)
+			return
+		}
+		viewModel.Baselines = durationBaselines(windowJobs)
+	}
+	if page > 1 {
+		viewModel.NewerURL = withQuery(r, "page", strconv.Itoa(page-1))
+	}
+	if hasOlder {
+		viewModel.OlderURL = withQuery(r, "page", strconv.Itoa(page+1))
 	}
 
 	// Execute template
 	err = h.templates.ExecuteTemplate(w, "testgrid.html", viewModel)
 	if err != nil {
-		log.Printf("Template execution error: %v", err)
+		slog.ErrorContext(r.Context(), "Template execution error", "url", r.URL.String(), "error", err)
 		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -177,8 +317,8 @@ func (h *Handler) handleTestGrid(w http.ResponseWriter, r *http.Request) {
 
 // handleJobDetails handles the job details view
 func (h *Handler) handleJobDetails(w http.ResponseWriter, r *http.Request, jobID string) {
-	// Fetch job from MongoDB
-	job, err := fetchJobFromMongoDB(jobID)
+	// Fetch the job
+	job, err := h.store.Job(jobID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error fetching job: %v", err), http.StatusInternalServerError)
 		return
@@ -188,28 +328,36 @@ func (h *Handler) handleJobDetails(w http.ResponseWriter, r *http.Request, jobID
 	summary := calculateTestSummary(job.Tests)
 
 	// Get failed tests
-	var failedTests []Test
+	var failedTests []FailedTest
 	for _, test := range job.Tests {
 		if strings.ToLower(test.Result) == "fail" {
-			failedTests = append(failedTests, test)
+			failedTests = append(failedTests, newFailedTest(test, len(failedTests)))
 		}
 	}
 
 	// Get the test to expand from query parameters
 	expandTest := r.URL.Query().Get("test")
 
+	// Find the previous job of the same PR to compare with
+	previousJob, err := h.store.PreviousJobID(*job)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error fetching previous job", "build_id", jobID, "error", err)
+	}
+
 	// Prepare view model
 	viewModel := JobDetailsViewModel{
 		Job:         *job,
 		Summary:     summary,
 		FailedTests: failedTests,
 		ExpandTest:  expandTest,
+		PreviousJob: previousJob,
+		Steps:       stepTimeline(*job),
 	}
 
 	// Execute template
 	err = h.templates.ExecuteTemplate(w, "jobdetails.html", viewModel)
 	if err != nil {
-		log.Printf("Template execution error: %v", err)
+		slog.ErrorContext(r.Context(), "Template execution error", "url", r.URL.String(), "error", err)
 		http.Error(w, fmt.Sprintf("Error rendering template: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -225,6 +373,8 @@ func getMongoDBURI() string {
 
 // fetchJobFromMongoDB retrieves a single job from MongoDB
 func fetchJobFromMongoDB(jobID string) (*Job, error) {
+	defer observeMongoQuery("job", time.Now())
+
 	// MongoDB connection configuration
 	clientOptions := options.Client().ApplyURI(getMongoDBURI())
 	client, err := mongo.Connect(context.TODO(), clientOptions)
@@ -269,13 +419,35 @@ func calculateTestSummary(tests []Test) TestSummary {
 	return summary
 }
 
-// fetchJobsFromMongoDB retrieves jobs from MongoDB
-func fetchJobsFromMongoDB(testName string, pr int) ([]Job, error) {
+// GridQuery selects the page of jobs shown in the grid
+type GridQuery struct {
+	TestName   string
+	PR         int  // Only jobs for this PR, if non-zero
+	FailedOnly bool // Only failed jobs
+	Window     TimeWindow
+	Page       int // 1-based page, newest jobs first
+	PageSize   int
+}
+
+// gridProjection excludes the test fields the grid doesn't render, which make
+// up most of the size of a job document
+var gridProjection = bson.M{
+	"tests.logs":           0,
+	"tests.hosted_cluster": 0,
+	"tests.nodepools":      0,
+	"steps":                0,
+}
+
+// fetchJobsFromMongoDB retrieves a page of jobs from MongoDB, newest first, and
+// reports whether there are older jobs after the page
+func fetchJobsFromMongoDB(query GridQuery) ([]Job, bool, error) {
+	defer observeMongoQuery("grid_jobs", time.Now())
+
 	// MongoDB connection configuration
 	clientOptions := options.Client().ApplyURI(getMongoDBURI())
 	client, err := mongo.Connect(context.TODO(), clientOptions)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	defer client.Disconnect(context.TODO())
 
@@ -284,30 +456,44 @@ func fetchJobsFromMongoDB(testName string, pr int) ([]Job, error) {
 
 	// Build query filter
 	filter := bson.M{
-		"started_at": bson.M{
-			"$gte": time.Now().AddDate(0, 0, -7).Format(time.RFC3339),
-		},
-		"test_name": testName,
+		"started_at": query.Window.mongoFilter(),
+		"test_name":  query.TestName,
 	}
 
 	// Add PR filter if specified
-	if pr > 0 {
-		filter["pr"] = pr
+	if query.PR > 0 {
+		filter["pr"] = query.PR
 	}
 
-	// Fetch jobs (last 7 days)
-	cursor, err := collection.Find(context.TODO(), filter)
+	// Add job result filter if specified
+	if query.FailedOnly {
+		filter["result"] = "FAILURE"
+	}
+
+	// Fetch one extra job to find out whether there is an older page
+	findOptions := options.Find().
+		SetProjection(gridProjection).
+		SetSort(bson.D{{Key: "started_at", Value: -1}}).
+		SetSkip(int64((query.Page - 1) * query.PageSize)).
+		SetLimit(int64(query.PageSize + 1))
+
+	cursor, err := collection.Find(context.TODO(), filter, findOptions)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	defer cursor.Close(context.TODO())
 
 	var jobs []Job
 	if err = cursor.All(context.TODO(), &jobs); err != nil {
-		return nil, err
+		return nil, false, err
+	}
+
+	hasOlder := len(jobs) > query.PageSize
+	if hasOlder {
+		jobs = jobs[:query.PageSize]
 	}
 
-	return jobs, nil
+	return jobs, hasOlder, nil
 }
 
 // extractTestGroups gets unique test group names and sorts them by failure status
@@ -356,7 +542,7 @@ func extractTestGroups(jobs []Job) []string {
 		}
 
 		// If both have failures, sort by most recent failure
-		if testGroups[i].HasRecentFailure && testGroups[j].HasRecentFailure {
+		if testGroups[i].HasRecentFailure && testGroups[j].HasRecentFailure && !testGroups[i].LastFailureAt.Equal(testGroups[j].LastFailureAt) {
 			return testGroups[i].LastFailureAt.After(testGroups[j].LastFailureAt)
 		}
 
@@ -389,11 +575,14 @@ func getTestResultInfo(job Job, testName string) TestResultInfo {
 			// Normalize result to lowercase
 			result := strings.ToLower(test.Result)
 			if result == "" {
-				return TestResultInfo{Result: "unknown", Logs: []string{}}
+				return TestResultInfo{Result: "unknown", Logs: []string{}, Ran: true}
 			}
 			return TestResultInfo{
-				Result: result,
-				Logs:   test.Logs,
+				Result:      result,
+				Logs:        test.Logs,
+				Duration:    test.Duration,
+				Ran:         true,
+				InfraReason: test.InfraReason,
 			}
 		}
 	}
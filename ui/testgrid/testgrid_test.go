@@ -0,0 +1,35 @@
+package testgrid
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractTestGroups(t *testing.T) {
+	jobs := []Job{
+		{StartedAt: "2026-10-18T12:00:00Z", Tests: []Test{
+			{Name: "TestF", Result: "fail"},
+			{Name: "TestB", Result: "fail"},
+			{Name: "TestD", Result: "pass"},
+		}},
+		{StartedAt: "2026-10-18T06:00:00Z", Tests: []Test{
+			{Name: "TestA", Result: "fail"},
+			{Name: "TestB", Result: "pass"},
+		}},
+		{StartedAt: "2026-10-17T12:00:00Z", Tests: []Test{
+			{Name: "TestC", Result: "fail"},
+			{Name: "TestD", Result: "pass"},
+			{Name: "TestA", Result: "fail"},
+			{Name: "TestE", Result: "skip"},
+		}},
+	}
+	// Failed tests first, most recent failure first, then by name
+	want := []string{"TestB", "TestF", "TestA", "TestC", "TestD", "TestE"}
+	// The order of tests that last failed in the same job doesn't depend on
+	// the order the map of test groups is sorted in
+	for range 20 {
+		if got := extractTestGroups(jobs); !slices.Equal(got, want) {
+			t.Fatalf("extractTestGroups = %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,372 @@
+package testgrid
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// statsCacheTTL is how long computed test stats are reused between scrapes,
+// to avoid querying MongoDB on every scrape
+const statsCacheTTL = time.Minute
+
+// DefaultStatsWindows are the windows test stats are computed over unless
+// configured otherwise
+const DefaultStatsWindows = "1d,7d"
+
+var (
+	jobPassRateDesc = prometheus.NewDesc(
+		"testgrid_job_pass_rate",
+		"Ratio of finished jobs of a test name that succeeded in the window.",
+		[]string{"test_name", "window"}, nil)
+	jobRunsDesc = prometheus.NewDesc(
+		"testgrid_job_runs",
+		"Number of finished jobs of a test name in the window.",
+		[]string{"test_name", "window"}, nil)
+	jobConsecutiveFailuresDesc = prometheus.NewDesc(
+		"testgrid_job_consecutive_failures",
+		"Number of latest finished jobs of a test name that failed in a row, within the longest window.",
+		[]string{"test_name"}, nil)
+	testFailureRateDesc = prometheus.NewDesc(
+		"testgrid_test_failure_rate",
+		"Ratio of runs of a test that failed in the window, out of its passed and failed runs.",
+		[]string{"test_name", "test", "window"}, nil)
+	testRunsDesc = prometheus.NewDesc(
+		"testgrid_test_runs",
+		"Number of passed and failed runs of a test in the window.",
+		[]string{"test_name", "test", "window"}, nil)
+	testConsecutiveFailuresDesc = prometheus.NewDesc(
+		"testgrid_test_consecutive_failures",
+		"Number of latest runs of a test that failed in a row, within the longest window.",
+		[]string{"test_name", "test"}, nil)
+)
+
+// StatsWindow is a window of time, ending now, that test stats are computed over
+type StatsWindow struct {
+	Label    string // As configured, e.g. 7d, used as the window label
+	Duration time.Duration
+}
+
+// ParseStatsWindows parses a comma-separated list of windows, given as a
+// number of days such as 7d or as a Go duration such as 12h
+func ParseStatsWindows(s string) ([]StatsWindow, error) {
+	var windows []StatsWindow
+	for _, label := range strings.Split(s, ",") {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			continue
+		}
+		var duration time.Duration
+		if days, ok := strings.CutSuffix(label, "d"); ok {
+			n, err := strconv.Atoi(days)
+			if err != nil {
+				return nil, fmt.Errorf("invalid window %q: %v", label, err)
+			}
+			duration = time.Duration(n) * 24 * time.Hour
+		} else {
+			var err error
+			duration, err = time.ParseDuration(label)
+			if err != nil {
+				return nil, fmt.Errorf("invalid window %q: %v", label, err)
+			}
+		}
+		if duration <= 0 {
+			return nil, fmt.Errorf("invalid window %q: must be positive", label)
+		}
+		windows = append(windows, StatsWindow{Label: label, Duration: duration})
+	}
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("no windows given")
+	}
+	return windows, nil
+}
+
+// JobStats are the stats of the jobs of a test name in a window
+type JobStats struct {
+	TestName string
+	Window   string
+	Runs     int // Finished jobs
+	Passed   int
+}
+
+// TestStats are the stats of a test's runs in a window
+type TestStats struct {
+	TestName string
+	Test     string
+	Window   string
+	Runs     int // Passed and failed runs
+	Failed   int
+}
+
+// ConsecutiveFailures is the length of the current failure streak of a test
+// name, or of a test if Test is set
+type ConsecutiveFailures struct {
+	TestName string
+	Test     string
+	Count    int
+}
+
+// testStatsSnapshot holds the stats computed from the jobs in the windows
+type testStatsSnapshot struct {
+	Jobs                    []JobStats
+	Tests                   []TestStats
+	JobConsecutiveFailures  []ConsecutiveFailures
+	TestConsecutiveFailures []ConsecutiveFailures
+}
+
+// statsCollector exports pass and failure rates computed from the stored
+// jobs, for alerting on specific test names and tests
+type statsCollector struct {
+	store   JobStore
+	windows []StatsWindow
+
+	mu       sync.Mutex
+	snapshot testStatsSnapshot
+	updated  time.Time
+}
+
+// NewStatsCollector returns a Prometheus collector of job pass rates, test
+// failure rates and failure streaks of the jobs of store over the given
+// windows
+func NewStatsCollector(store JobStore, windows []StatsWindow) prometheus.Collector {
+	return &statsCollector{store: store, windows: windows}
+}
+
+// Describe implements prometheus.Collector
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- jobPassRateDesc
+	ch <- jobRunsDesc
+	ch <- jobConsecutiveFailuresDesc
+	ch <- testFailureRateDesc
+	ch <- testRunsDesc
+	ch <- testConsecutiveFailuresDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	snapshot, err := c.stats()
+	if err != nil {
+		slog.Error("Error computing test stats", "error", err)
+		ch <- prometheus.NewInvalidMetric(jobPassRateDesc, err)
+		return
+	}
+
+	for _, s := range snapshot.Jobs {
+		ch <- prometheus.MustNewConstMetric(jobRunsDesc, prometheus.GaugeValue, float64(s.Runs), s.TestName, s.Window)
+		if s.Runs > 0 {
+			ch <- prometheus.MustNewConstMetric(jobPassRateDesc, prometheus.GaugeValue, float64(s.Passed)/float64(s.Runs), s.TestName, s.Window)
+		}
+	}
+	for _, s := range snapshot.Tests {
+		ch <- prometheus.MustNewConstMetric(testRunsDesc, prometheus.GaugeValue, float64(s.Runs), s.TestName, s.Test, s.Window)
+		if s.Runs > 0 {
+			ch <- prometheus.MustNewConstMetric(testFailureRateDesc, prometheus.GaugeValue, float64(s.Failed)/float64(s.Runs), s.TestName, s.Test, s.Window)
+		}
+	}
+	for _, s := range snapshot.JobConsecutiveFailures {
+		ch <- prometheus.MustNewConstMetric(jobConsecutiveFailuresDesc, prometheus.GaugeValue, float64(s.Count), s.TestName)
+	}
+	for _, s := range snapshot.TestConsecutiveFailures {
+		ch <- prometheus.MustNewConstMetric(testConsecutiveFailuresDesc, prometheus.GaugeValue, float64(s.Count), s.TestName, s.Test)
+	}
+}
+
+// stats returns the cached stats, recomputing them if they are older than
+// statsCacheTTL
+func (c *statsCollector) stats() (testStatsSnapshot, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if !c.updated.IsZero() && now.Sub(c.updated) < statsCacheTTL {
+		return c.snapshot, nil
+	}
+
+	jobs, err := c.store.StatsJobs(now.Add(-longestWindow(c.windows)))
+	if err != nil {
+		return testStatsSnapshot{}, err
+	}
+	c.snapshot = computeTestStats(jobs, c.windows, now)
+	c.updated = now
+	return c.snapshot, nil
+}
+
+// longestWindow returns the duration of the longest window
+func longestWindow(windows []StatsWindow) time.Duration {
+	var longest time.Duration
+	for _, w := range windows {
+		if w.Duration > longest {
+			longest = w.Duration
+		}
+	}
+	return longest
+}
+
+// fetchStatsJobsFromMongoDB retrieves the jobs started since the given time,
+// newest first, with only their tests' names and results
+func fetchStatsJobsFromMongoDB(since time.Time) ([]Job, error) {
+	defer observeMongoQuery("test_stats", time.Now())
+
+	// MongoDB connection configuration
+	clientOptions := options.Client().ApplyURI(getMongoDBURI())
+	client, err := mongo.Connect(context.TODO(), clientOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Disconnect(context.TODO())
+
+	// Select database and collection
+	collection := client.Database("ci").Collection("jobs")
+
+	filter := bson.M{
+		"started_at": TimeWindow{From: since}.mongoFilter(),
+	}
+	projection := bson.M{
+		"result":       1,
+		"started_at":   1,
+		"test_name":    1,
+		"tests.name":   1,
+		"tests.result": 1,
+	}
+	findOptions := options.Find().
+		SetProjection(projection).
+		SetSort(bson.D{{Key: "started_at", Value: -1}})
+
+	cursor, err := collection.Find(context.TODO(), filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	var jobs []Job
+	if err = cursor.All(context.TODO(), &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// computeTestStats computes the stats of the jobs, given newest first, in each
+// window ending at now. Only finished jobs, and passed or failed test runs,
+// are counted. Failure streaks are counted over all the given jobs.
+func computeTestStats(jobs []Job, windows []StatsWindow, now time.Time) testStatsSnapshot {
+	var snapshot testStatsSnapshot
+
+	type testKey struct{ testName, test string }
+	for _, window := range windows {
+		since := now.Add(-window.Duration).UTC().Format(time.RFC3339)
+		jobStats := make(map[string]*JobStats)
+		var jobOrder []string
+		testStats := make(map[testKey]*TestStats)
+		var testOrder []testKey
+		for _, job := range jobs {
+			if job.StartedAt < since {
+				continue
+			}
+			result := strings.ToUpper(job.Result)
+			if result != "SUCCESS" && result != "FAILURE" {
+				continue
+			}
+			js, ok := jobStats[job.TestName]
+			if !ok {
+				js = &JobStats{TestName: job.TestName, Window: window.Label}
+				jobStats[job.TestName] = js
+				jobOrder = append(jobOrder, job.TestName)
+			}
+			js.Runs++
+			if result == "SUCCESS" {
+				js.Passed++
+			}
+
+			for _, test := range job.Tests {
+				testResult := strings.ToLower(test.Result)
+				if testResult != "pass" && testResult != "fail" {
+					continue
+				}
+				key := testKey{job.TestName, test.Name}
+				ts, ok := testStats[key]
+				if !ok {
+					ts = &TestStats{TestName: job.TestName, Test: test.Name, Window: window.Label}
+					testStats[key] = ts
+					testOrder = append(testOrder, key)
+				}
+				ts.Runs++
+				if testResult == "fail" {
+					ts.Failed++
+				}
+			}
+		}
+		for _, testName := range jobOrder {
+			snapshot.Jobs = append(snapshot.Jobs, *jobStats[testName])
+		}
+		for _, key := range testOrder {
+			snapshot.Tests = append(snapshot.Tests, *testStats[key])
+		}
+	}
+
+	// Failure streaks end at the latest passed run, newest first
+	jobStreaks := make(map[string]*ConsecutiveFailures)
+	jobStreakEnded := make(map[string]bool)
+	var jobStreakOrder []string
+	testStreaks := make(map[testKey]*ConsecutiveFailures)
+	testStreakEnded := make(map[testKey]bool)
+	var testStreakOrder []testKey
+	for _, job := range jobs {
+		result := strings.ToUpper(job.Result)
+		if result != "SUCCESS" && result != "FAILURE" {
+			continue
+		}
+		streak, ok := jobStreaks[job.TestName]
+		if !ok {
+			streak = &ConsecutiveFailures{TestName: job.TestName}
+			jobStreaks[job.TestName] = streak
+			jobStreakOrder = append(jobStreakOrder, job.TestName)
+		}
+		if !jobStreakEnded[job.TestName] {
+			if result == "FAILURE" {
+				streak.Count++
+			} else {
+				jobStreakEnded[job.TestName] = true
+			}
+		}
+
+		for _, test := range job.Tests {
+			testResult := strings.ToLower(test.Result)
+			if testResult != "pass" && testResult != "fail" {
+				continue
+			}
+			key := testKey{job.TestName, test.Name}
+			streak, ok := testStreaks[key]
+			if !ok {
+				streak = &ConsecutiveFailures{TestName: job.TestName, Test: test.Name}
+				testStreaks[key] = streak
+				testStreakOrder = append(testStreakOrder, key)
+			}
+			if testStreakEnded[key] {
+				continue
+			}
+			if testResult == "fail" {
+				streak.Count++
+			} else {
+				testStreakEnded[key] = true
+			}
+		}
+	}
+	for _, testName := range jobStreakOrder {
+		snapshot.JobConsecutiveFailures = append(snapshot.JobConsecutiveFailures, *jobStreaks[testName])
+	}
+	for _, key := range testStreakOrder {
+		snapshot.TestConsecutiveFailures = append(snapshot.TestConsecutiveFailures, *testStreaks[key])
+	}
+
+	return snapshot
+}
@@ -0,0 +1,124 @@
+package testgrid
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParseStatsWindows(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []StatsWindow
+		wantErr bool
+	}{
+		{
+			input: DefaultStatsWindows,
+			want:  []StatsWindow{{Label: "1d", Duration: 24 * time.Hour}, {Label: "7d", Duration: 7 * 24 * time.Hour}},
+		},
+		{
+			input: " 12h , 30d,",
+			want:  []StatsWindow{{Label: "12h", Duration: 12 * time.Hour}, {Label: "30d", Duration: 30 * 24 * time.Hour}},
+		},
+		{input: "", wantErr: true},
+		{input: " , ", wantErr: true},
+		{input: "weekd", wantErr: true},
+		{input: "7w", wantErr: true},
+		{input: "0d", wantErr: true},
+		{input: "-12h", wantErr: true},
+		{input: "1d,x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseStatsWindows(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseStatsWindows(%q) = %v, want an error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseStatsWindows(%q) returned error: %v", tt.input, err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("ParseStatsWindows(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeTestStats(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	hoursAgo := func(hours int) string {
+		return now.Add(-time.Duration(hours) * time.Hour).Format(time.RFC3339)
+	}
+	windows := []StatsWindow{{Label: "1d", Duration: 24 * time.Hour}, {Label: "7d", Duration: 7 * 24 * time.Hour}}
+
+	// Newest first
+	jobs := []Job{
+		{TestName: "e2e-aws", Result: "PENDING", StartedAt: hoursAgo(1), Tests: []Test{{Name: "TestA", Result: "fail"}}},
+		{TestName: "e2e-aws", Result: "FAILURE", StartedAt: hoursAgo(2), Tests: []Test{{Name: "TestA", Result: "fail"}, {Name: "TestB", Result: "skip"}}},
+		{TestName: "e2e-aks", Result: "SUCCESS", StartedAt: hoursAgo(3), Tests: []Test{{Name: "TestA", Result: "pass"}}},
+		{TestName: "e2e-aws", Result: "FAILURE", StartedAt: hoursAgo(30), Tests: []Test{{Name: "TestA", Result: "fail"}, {Name: "TestB", Result: "pass"}}},
+		{TestName: "e2e-aws", Result: "SUCCESS", StartedAt: hoursAgo(50), Tests: []Test{{Name: "TestA", Result: "pass"}, {Name: "TestB", Result: "fail"}}},
+		{TestName: "e2e-aws", Result: "ABORTED", StartedAt: hoursAgo(60), Tests: []Test{{Name: "TestA", Result: "fail"}}},
+		{TestName: "e2e-aws", Result: "FAILURE", StartedAt: hoursAgo(200), Tests: []Test{{Name: "TestA", Result: "fail"}}},
+	}
+
+	got := computeTestStats(jobs, windows, now)
+
+	// Pending and aborted jobs aren't counted, nor are skipped tests
+	wantJobs := []JobStats{
+		{TestName: "e2e-aws", Window: "1d", Runs: 1, Passed: 0},
+		{TestName: "e2e-aks", Window: "1d", Runs: 1, Passed: 1},
+		{TestName: "e2e-aws", Window: "7d", Runs: 3, Passed: 1},
+		{TestName: "e2e-aks", Window: "7d", Runs: 1, Passed: 1},
+	}
+	if fmt.Sprint(got.Jobs) != fmt.Sprint(wantJobs) {
+		t.Errorf("job stats = %+v, want %+v", got.Jobs, wantJobs)
+	}
+	wantTests := []TestStats{
+		{TestName: "e2e-aws", Test: "TestA", Window: "1d", Runs: 1, Failed: 1},
+		{TestName: "e2e-aks", Test: "TestA", Window: "1d", Runs: 1, Failed: 0},
+		{TestName: "e2e-aws", Test: "TestA", Window: "7d", Runs: 3, Failed: 2},
+		{TestName: "e2e-aks", Test: "TestA", Window: "7d", Runs: 1, Failed: 0},
+		{TestName: "e2e-aws", Test: "TestB", Window: "7d", Runs: 2, Failed: 1},
+	}
+	if fmt.Sprint(got.Tests) != fmt.Sprint(wantTests) {
+		t.Errorf("test stats = %+v, want %+v", got.Tests, wantTests)
+	}
+
+	// Streaks run from the newest finished job back to the latest pass, and
+	// skipped runs neither extend nor end them
+	wantJobStreaks := []ConsecutiveFailures{
+		{TestName: "e2e-aws", Count: 2},
+		{TestName: "e2e-aks", Count: 0},
+	}
+	if fmt.Sprint(got.JobConsecutiveFailures) != fmt.Sprint(wantJobStreaks) {
+		t.Errorf("job streaks = %+v, want %+v", got.JobConsecutiveFailures, wantJobStreaks)
+	}
+	wantTestStreaks := []ConsecutiveFailures{
+		{TestName: "e2e-aws", Test: "TestA", Count: 2},
+		{TestName: "e2e-aks", Test: "TestA", Count: 0},
+		{TestName: "e2e-aws", Test: "TestB", Count: 0},
+	}
+	if fmt.Sprint(got.TestConsecutiveFailures) != fmt.Sprint(wantTestStreaks) {
+		t.Errorf("test streaks = %+v, want %+v", got.TestConsecutiveFailures, wantTestStreaks)
+	}
+}
+
+func TestStatsCollectorStore(t *testing.T) {
+	// The window reaches back to the scraped jobs, whatever the date
+	windows := []StatsWindow{{Label: "all", Duration: time.Since(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))}}
+	collector := NewStatsCollector(loadMemoryStore(t, scrapedJobs), windows).(*statsCollector)
+
+	snapshot, err := collector.stats()
+	if err != nil {
+		t.Fatalf("stats returned error: %v", err)
+	}
+	want := JobStats{TestName: "e2e-aws", Window: "all", Runs: 4, Passed: 1}
+	if len(snapshot.Jobs) != 1 || snapshot.Jobs[0] != want {
+		t.Errorf("job stats = %+v, want %+v", snapshot.Jobs, want)
+	}
+}
@@ -0,0 +1,159 @@
+package testgrid
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// defaultWindowDays is the time window shown when none is requested
+	defaultWindowDays = 7
+	// defaultPageSize is the number of job columns shown per grid page
+	defaultPageSize = 50
+	// maxPageSize caps the pageSize query parameter
+	maxPageSize = 200
+)
+
+// dateLayout is the format of the date-only from/to query parameters
+const dateLayout = "2006-01-02"
+
+// TimeWindow is the range of job start times to show
+type TimeWindow struct {
+	From time.Time
+	To   time.Time // Zero means up to now
+}
+
+// parseTimeWindow reads the from, to and days query parameters. from and to
+// accept a date or an RFC3339 timestamp; a date-only to includes the whole
+// day. days counts back from to (or now) and is ignored when from is set.
+func parseTimeWindow(query url.Values, now time.Time) (TimeWindow, error) {
+	var window TimeWindow
+
+	if toStr := query.Get("to"); toStr != "" {
+		to, dateOnly, err := parseTimeParam(toStr)
+		if err != nil {
+			return window, fmt.Errorf("invalid to parameter: %v", err)
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		window.To = to
+	}
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, _, err := parseTimeParam(fromStr)
+		if err != nil {
+			return window, fmt.Errorf("invalid from parameter: %v", err)
+		}
+		window.From = from
+	} else {
+		days := defaultWindowDays
+		if daysStr := query.Get("days"); daysStr != "" {
+			d, err := strconv.Atoi(daysStr)
+			if err != nil || d <= 0 {
+				return window, fmt.Errorf("invalid days parameter: %q", daysStr)
+			}
+			days = d
+		}
+		end := now
+		if !window.To.IsZero() {
+			end = window.To
+		}
+		window.From = end.AddDate(0, 0, -days)
+	}
+
+	if !window.To.IsZero() && !window.From.Before(window.To) {
+		return window, fmt.Errorf("from must be before to")
+	}
+
+	return window, nil
+}
+
+// parseTimeParam parses a date or RFC3339 timestamp, reporting whether it was
+// a date.
+func parseTimeParam(s string) (time.Time, bool, error) {
+	if t, err := time.Parse(dateLayout, s); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	return t, false, err
+}
+
+// mongoFilter returns the started_at filter for the window
+func (w TimeWindow) mongoFilter() bson.M {
+	filter := bson.M{
+		"$gte": w.From.UTC().Format(time.RFC3339),
+	}
+	if !w.To.IsZero() {
+		filter["$lt"] = w.To.UTC().Format(time.RFC3339)
+	}
+	return filter
+}
+
+// parsePage reads the 1-based page and pageSize query parameters
+func parsePage(query url.Values) (int, int, error) {
+	page := 1
+	if pageStr := query.Get("page"); pageStr != "" {
+		p, err := strconv.Atoi(pageStr)
+		if err != nil || p < 1 {
+			return 0, 0, fmt.Errorf("invalid page parameter: %q", pageStr)
+		}
+		page = p
+	}
+
+	pageSize := defaultPageSize
+	if sizeStr := query.Get("pageSize"); sizeStr != "" {
+		s, err := strconv.Atoi(sizeStr)
+		if err != nil || s < 1 {
+			return 0, 0, fmt.Errorf("invalid pageSize parameter: %q", sizeStr)
+		}
+		pageSize = s
+		if pageSize > maxPageSize {
+			pageSize = maxPageSize
+		}
+	}
+
+	return page, pageSize, nil
+}
+
+// withQuery returns a link to the current request's query with key set to
+// value, or removed if value is empty, and the drop parameters removed
+func withQuery(r *http.Request, key, value string, drop ...string) string {
+	q := r.URL.Query()
+	if value == "" {
+		q.Del(key)
+	} else {
+		q.Set(key, value)
+	}
+	for _, name := range drop {
+		q.Del(name)
+	}
+	return "?" + q.Encode()
+}
+
+// WindowPreset links to the grid over the last few days
+type WindowPreset struct {
+	Label string
+	URL   string
+}
+
+// windowPresetDays are the time windows offered as presets
+var windowPresetDays = []int{1, 7, 14, 30}
+
+// windowPresets returns links to the current request's query over each preset
+// window, starting from the first page
+func windowPresets(r *http.Request) []WindowPreset {
+	var presets []WindowPreset
+	for _, days := range windowPresetDays {
+		presets = append(presets, WindowPreset{
+			Label: fmt.Sprintf("%dd", days),
+			URL:   withQuery(r, "days", strconv.Itoa(days), "from", "to", "page"),
+		})
+	}
+	return presets
+}
@@ -0,0 +1,264 @@
+package testgrid
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseTimeWindow(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	date := func(s string) time.Time {
+		d, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    TimeWindow
+		wantErr bool
+	}{
+		{
+			name:  "default",
+			query: "",
+			want:  TimeWindow{From: now.AddDate(0, 0, -defaultWindowDays)},
+		},
+		{
+			name:  "days",
+			query: "days=1",
+			want:  TimeWindow{From: now.AddDate(0, 0, -1)},
+		},
+		{
+			name:  "dates",
+			query: "from=2026-10-01&to=2026-10-05",
+			// A date-only to includes the whole day
+			want: TimeWindow{From: date("2026-10-01T00:00:00Z"), To: date("2026-10-06T00:00:00Z")},
+		},
+		{
+			name:  "timestamps",
+			query: "from=2026-10-01T06:00:00Z&to=2026-10-05T18:00:00Z",
+			want:  TimeWindow{From: date("2026-10-01T06:00:00Z"), To: date("2026-10-05T18:00:00Z")},
+		},
+		{
+			name:  "days before to",
+			query: "to=2026-10-05&days=2",
+			want:  TimeWindow{From: date("2026-10-04T00:00:00Z"), To: date("2026-10-06T00:00:00Z")},
+		},
+		{
+			name:  "days ignored with from",
+			query: "from=2026-10-01&days=2",
+			want:  TimeWindow{From: date("2026-10-01T00:00:00Z")},
+		},
+		{
+			name:    "invalid from",
+			query:   "from=yesterday",
+			wantErr: true,
+		},
+		{
+			name:    "invalid to",
+			query:   "to=2026-13-01",
+			wantErr: true,
+		},
+		{
+			name:    "zero days",
+			query:   "days=0",
+			wantErr: true,
+		},
+		{
+			name:    "non-numeric days",
+			query:   "days=week",
+			wantErr: true,
+		},
+		{
+			name:    "from after to",
+			query:   "from=2026-10-05&to=2026-10-01",
+			wantErr: true,
+		},
+		{
+			name:    "from equal to to",
+			query:   "from=2026-10-05T00:00:00Z&to=2026-10-05T00:00:00Z",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parseTimeWindow(query, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseTimeWindow(%q) = %+v, want an error", tt.query, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTimeWindow(%q) returned error: %v", tt.query, err)
+			}
+			if !got.From.Equal(tt.want.From) || !got.To.Equal(tt.want.To) {
+				t.Errorf("parseTimeWindow(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePage(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		wantPage     int
+		wantPageSize int
+		wantErr      bool
+	}{
+		{
+			name:         "default",
+			query:        "",
+			wantPage:     1,
+			wantPageSize: 50,
+		},
+		{
+			name:         "page and size",
+			query:        "page=3&pageSize=20",
+			wantPage:     3,
+			wantPageSize: 20,
+		},
+		{
+			name:         "maximum size",
+			query:        "pageSize=200",
+			wantPage:     1,
+			wantPageSize: 200,
+		},
+		{
+			name:         "size above the maximum",
+			query:        "pageSize=1000",
+			wantPage:     1,
+			wantPageSize: 200,
+		},
+		{
+			name:    "zero page",
+			query:   "page=0",
+			wantErr: true,
+		},
+		{
+			name:    "negative page",
+			query:   "page=-1",
+			wantErr: true,
+		},
+		{
+			name:    "non-numeric page",
+			query:   "page=next",
+			wantErr: true,
+		},
+		{
+			name:    "zero size",
+			query:   "pageSize=0",
+			wantErr: true,
+		},
+		{
+			name:    "non-numeric size",
+			query:   "pageSize=all",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			page, pageSize, err := parsePage(query)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parsePage(%q) = %d, %d, want an error", tt.query, page, pageSize)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePage(%q) returned error: %v", tt.query, err)
+			}
+			if page != tt.wantPage || pageSize != tt.wantPageSize {
+				t.Errorf("parsePage(%q) = %d, %d, want %d, %d", tt.query, page, pageSize, tt.wantPage, tt.wantPageSize)
+			}
+		})
+	}
+}
+
+func TestWithQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		key   string
+		value string
+		drop  []string
+		want  string
+	}{
+		{
+			name:  "add",
+			query: "testName=e2e-aws",
+			key:   "page",
+			value: "2",
+			want:  "?page=2&testName=e2e-aws",
+		},
+		{
+			name:  "replace",
+			query: "page=2&testName=e2e-aws&days=14",
+			key:   "page",
+			value: "3",
+			want:  "?days=14&page=3&testName=e2e-aws",
+		},
+		{
+			name:  "remove",
+			query: "page=2&testName=e2e-aws",
+			key:   "page",
+			want:  "?testName=e2e-aws",
+		},
+		{
+			name:  "drop",
+			query: "from=2026-10-01&to=2026-10-10&page=2&testName=e2e-aws",
+			key:   "days",
+			value: "7",
+			drop:  []string{"from", "to", "page"},
+			want:  "?days=7&testName=e2e-aws",
+		},
+		{
+			name: "empty query",
+			key:  "page",
+			want: "?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/?"+tt.query, nil)
+			if got := withQuery(r, tt.key, tt.value, tt.drop...); got != tt.want {
+				t.Errorf("withQuery(%q, %q, %q, %q) = %s, want %s", tt.query, tt.key, tt.value, tt.drop, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowPresets(t *testing.T) {
+	r := httptest.NewRequest("GET", "/?testName=e2e-aws&from=2026-10-01&to=2026-10-10&page=3&include=Node", nil)
+	var got []string
+	for _, preset := range windowPresets(r) {
+		got = append(got, preset.Label+" "+preset.URL)
+	}
+	want := []string{
+		"1d ?days=1&include=Node&testName=e2e-aws",
+		"7d ?days=7&include=Node&testName=e2e-aws",
+		"14d ?days=14&include=Node&testName=e2e-aws",
+		"30d ?days=30&include=Node&testName=e2e-aws",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("windowPresets = %q, want %q", got, want)
+	}
+}